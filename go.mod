@@ -1,3 +1,5 @@
 module github.com/bsanzhiev/go-exercises
 
-go 1.23
+go 1.23.0
+
+require golang.org/x/net v0.38.0
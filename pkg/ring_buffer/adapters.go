@@ -0,0 +1,87 @@
+package ringbuffer
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator that removes and yields items until the buffer
+// is closed and drained. Breaking out of the loop leaves the remaining
+// items in the buffer.
+func (rb *RingBuffer) All() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for {
+			item, err := rb.Get()
+			if err != nil {
+				return
+			}
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// OutContext returns a channel fed from the buffer by a background
+// goroutine. The channel is closed once the buffer is closed and drained,
+// so every item is delivered even after Close. A receiver that stops early
+// must cancel ctx: the channel is then closed and the item in flight is put
+// back, so nothing is lost and the rest can still be read with Get or All.
+func (rb *RingBuffer) OutContext(ctx context.Context) <-chan interface{} {
+	out := make(chan interface{})
+	stop := context.AfterFunc(ctx, func() {
+		// wake the pump if it is blocked in Get
+		rb.mu.Lock()
+		rb.notEmpty.Broadcast()
+		rb.mu.Unlock()
+	})
+	go func() {
+		defer close(out)
+		defer stop()
+		for {
+			item, err := rb.get(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				rb.putFront(item)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// In returns a channel whose values are Put into the buffer by a
+// background goroutine, blocking while the buffer is full. The caller owns
+// the channel: the goroutine runs until the caller closes it, not until the
+// buffer is closed. Values sent after Close are discarded so that senders
+// never block on a closed buffer; close the channel before closing the
+// buffer to avoid losing them.
+func (rb *RingBuffer) In() chan<- interface{} {
+	in := make(chan interface{})
+	go func() {
+		for item := range in {
+			// after Close, Put fails and the item is dropped
+			_ = rb.Put(item)
+		}
+	}()
+	return in
+}
+
+// putFront returns an item to the head of the queue, growing the buffer by
+// one slot if other producers have filled it in the meantime.
+func (rb *RingBuffer) putFront(item interface{}) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.count == rb.size {
+		rb.resize(rb.size + 1)
+	}
+	rb.readIdx = (rb.readIdx - 1 + rb.size) % rb.size
+	rb.buffer[rb.readIdx] = item
+	rb.count++
+	rb.notEmpty.Signal()
+}
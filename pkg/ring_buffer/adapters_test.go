@@ -0,0 +1,133 @@
+package ringbuffer
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRingBufferAll(t *testing.T) {
+	rb := NewRingBuffer(4)
+	for i := 0; i < 3; i++ {
+		_ = rb.Put(i)
+	}
+	rb.Close()
+
+	expected := 0
+	for item := range rb.All() {
+		if item != expected {
+			t.Errorf("expected %d, got %v", expected, item)
+		}
+		expected++
+	}
+	if expected != 3 {
+		t.Errorf("expected 3 items, got %d", expected)
+	}
+}
+
+func TestRingBufferInOut(t *testing.T) {
+	rb := NewRingBuffer(2)
+	in := rb.In()
+	out := rb.OutContext(context.Background())
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	for i := 0; i < 10; i++ {
+		if item := <-out; item != i {
+			t.Errorf("expected %d, got %v", i, item)
+		}
+	}
+	rb.Close()
+	if _, ok := <-out; ok {
+		t.Errorf("expected Out to be closed")
+	}
+}
+
+func TestRingBufferOutAfterClose(t *testing.T) {
+	for run := 0; run < 100; run++ {
+		rb := NewRingBuffer(16)
+		for i := 0; i < 10; i++ {
+			_ = rb.Put(i)
+		}
+		rb.Close()
+
+		n := 0
+		for item := range rb.OutContext(context.Background()) {
+			if item != n {
+				t.Fatalf("expected %d, got %v", n, item)
+			}
+			n++
+		}
+		if n != 10 {
+			t.Fatalf("expected 10 items after Close, got %d", n)
+		}
+	}
+}
+
+func TestRingBufferOutContextStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	rb := NewRingBuffer(2)
+	_ = rb.Put("A")
+	_ = rb.Put("B")
+	ctx, cancel := context.WithCancel(context.Background())
+	out := rb.OutContext(ctx)
+	idle := rb.OutContext(ctx)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	for range out {
+	}
+	for range idle {
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected adapter goroutines to exit, %d still running", n-before)
+	}
+
+	// The items taken by the pumps are returned to the buffer.
+	got := map[interface{}]bool{}
+	for rb.Len() > 0 {
+		item, _ := rb.Get()
+		got[item] = true
+	}
+	if !got["A"] || !got["B"] {
+		t.Errorf("expected A and B back in the buffer, got %v", got)
+	}
+}
+
+func TestRingBufferInAfterClose(t *testing.T) {
+	rb := NewRingBuffer(1)
+	in := rb.In()
+	in <- 1
+	for rb.Len() == 0 {
+		runtime.Gosched()
+	}
+	rb.Close()
+
+	// Senders are not blocked once the buffer is closed.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			in <- i
+		}
+		close(in)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sender blocked after Close")
+	}
+	if item, err := rb.Get(); err != nil || item != 1 {
+		t.Errorf("expected the item sent before Close, got %v, %v", item, err)
+	}
+}
@@ -0,0 +1,219 @@
+package ringbuffer
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+Byte Ring Buffer:
+Кольцевой буфер байтов с интерфейсами io.Reader и io.Writer
+Блокируется как RingBuffer, поэтому работает как ограниченный io.Pipe
+Поддерживает дедлайны чтения и записи и закрытие с ошибкой
+*/
+
+const byteRingCopySize = 32 * 1024
+
+// ByteRingBuffer is a bounded in-memory pipe. Read blocks until data is
+// available and Write blocks until all of p has been buffered.
+type ByteRingBuffer struct {
+	buffer   []byte
+	readIdx  int
+	writeIdx int
+	count    int
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	closed   bool
+	closeErr error
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func NewByteRingBuffer(size int) *ByteRingBuffer {
+	b := &ByteRingBuffer{buffer: make([]byte, size)}
+	b.notEmpty = sync.NewCond(&b.mu)
+	b.notFull = sync.NewCond(&b.mu)
+	return b
+}
+
+// Read reads up to len(p) buffered bytes. Once the buffer is closed and
+// drained it returns the close error, io.EOF for a plain Close.
+func (b *ByteRingBuffer) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for b.count == 0 {
+		if b.closed {
+			return 0, b.closeErr
+		}
+		if err := b.wait(b.notEmpty, b.readDeadline); err != nil {
+			return 0, err
+		}
+	}
+
+	n := b.copyOut(p)
+	b.notFull.Broadcast()
+	return n, nil
+}
+
+// Write buffers all of p, blocking while the buffer is full. It returns
+// io.ErrClosedPipe once the buffer is closed.
+func (b *ByteRingBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		if b.closed {
+			return written, io.ErrClosedPipe
+		}
+		if b.count == len(b.buffer) {
+			if err := b.wait(b.notFull, b.writeDeadline); err != nil {
+				return written, err
+			}
+			continue
+		}
+		written += b.copyIn(p[written:])
+		b.notEmpty.Broadcast()
+	}
+	return written, nil
+}
+
+// WriteTo drains the buffer into w until it is closed.
+func (b *ByteRingBuffer) WriteTo(w io.Writer) (int64, error) {
+	chunk := make([]byte, min(byteRingCopySize, max(len(b.buffer), 1)))
+	var total int64
+	for {
+		n, err := b.Read(chunk)
+		if n > 0 {
+			m, werr := w.Write(chunk[:n])
+			total += int64(m)
+			if werr != nil {
+				return total, werr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// ReadFrom fills the buffer from r until r returns io.EOF.
+func (b *ByteRingBuffer) ReadFrom(r io.Reader) (int64, error) {
+	chunk := make([]byte, min(byteRingCopySize, max(len(b.buffer), 1)))
+	var total int64
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			m, werr := b.Write(chunk[:n])
+			total += int64(m)
+			if werr != nil {
+				return total, werr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// SetReadDeadline makes blocked and future Reads fail with
+// os.ErrDeadlineExceeded after t. A zero t disables the deadline.
+func (b *ByteRingBuffer) SetReadDeadline(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readDeadline = t
+	b.notEmpty.Broadcast()
+}
+
+// SetWriteDeadline makes blocked and future Writes fail with
+// os.ErrDeadlineExceeded after t. A zero t disables the deadline.
+func (b *ByteRingBuffer) SetWriteDeadline(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writeDeadline = t
+	b.notFull.Broadcast()
+}
+
+func (b *ByteRingBuffer) Close() error {
+	return b.CloseWithError(nil)
+}
+
+// CloseWithError closes the buffer. Writers get io.ErrClosedPipe; readers
+// get the remaining data and then err, or io.EOF if err is nil.
+func (b *ByteRingBuffer) CloseWithError(err error) error {
+	if err == nil {
+		err = io.EOF
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		b.closeErr = err
+	}
+	b.notEmpty.Broadcast()
+	b.notFull.Broadcast()
+	return nil
+}
+
+func (b *ByteRingBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// wait blocks on cond until it is signalled or the deadline passes.
+// Callers must hold b.mu and re-check their condition afterwards.
+func (b *ByteRingBuffer) wait(cond *sync.Cond, deadline time.Time) error {
+	if deadline.IsZero() {
+		cond.Wait()
+		return nil
+	}
+	d := time.Until(deadline)
+	if d <= 0 {
+		return os.ErrDeadlineExceeded
+	}
+	timer := time.AfterFunc(d, func() {
+		b.mu.Lock()
+		cond.Broadcast()
+		b.mu.Unlock()
+	})
+	cond.Wait()
+	timer.Stop()
+	return nil
+}
+
+// copyOut moves buffered bytes into p. Callers must hold b.mu.
+func (b *ByteRingBuffer) copyOut(p []byte) int {
+	n := min(len(p), b.count)
+	first := min(n, len(b.buffer)-b.readIdx)
+	copy(p, b.buffer[b.readIdx:b.readIdx+first])
+	copy(p[first:n], b.buffer[:n-first])
+	b.readIdx = (b.readIdx + n) % len(b.buffer)
+	b.count -= n
+	return n
+}
+
+// copyIn moves as much of p as fits into free space. Callers must hold b.mu.
+func (b *ByteRingBuffer) copyIn(p []byte) int {
+	n := min(len(p), len(b.buffer)-b.count)
+	first := min(n, len(b.buffer)-b.writeIdx)
+	copy(b.buffer[b.writeIdx:], p[:first])
+	copy(b.buffer, p[first:n])
+	b.writeIdx = (b.writeIdx + n) % len(b.buffer)
+	b.count += n
+	return n
+}
@@ -0,0 +1,69 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestByteRingBufferPipe(t *testing.T) {
+	b := NewByteRingBuffer(7)
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	go func() {
+		if _, err := b.ReadFrom(bytes.NewReader(data)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		b.Close()
+	}()
+
+	var out bytes.Buffer
+	n, err := b.WriteTo(&out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("expected %d bytes to round-trip, got %d", len(data), n)
+	}
+}
+
+func TestByteRingBufferCloseWithError(t *testing.T) {
+	b := NewByteRingBuffer(8)
+	_, _ = b.Write([]byte("abc"))
+	errBoom := errors.New("boom")
+	b.CloseWithError(errBoom)
+
+	if _, err := b.Write([]byte("d")); err != io.ErrClosedPipe {
+		t.Errorf("expected io.ErrClosedPipe, got %v", err)
+	}
+	buf := make([]byte, 8)
+	if n, err := b.Read(buf); n != 3 || err != nil {
+		t.Errorf("expected buffered data first, got %d, %v", n, err)
+	}
+	if _, err := b.Read(buf); err != errBoom {
+		t.Errorf("expected close error, got %v", err)
+	}
+}
+
+func TestByteRingBufferDeadlines(t *testing.T) {
+	b := NewByteRingBuffer(2)
+	b.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := b.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected deadline error on read, got %v", err)
+	}
+
+	b.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	n, err := b.Write([]byte("abc"))
+	if !errors.Is(err, os.ErrDeadlineExceeded) || n != 2 {
+		t.Errorf("expected 2 bytes and deadline error on write, got %d, %v", n, err)
+	}
+
+	b.SetReadDeadline(time.Time{})
+	buf := make([]byte, 2)
+	if n, err := b.Read(buf); n != 2 || err != nil || string(buf) != "ab" {
+		t.Errorf("expected \"ab\", got %q, %v", buf[:n], err)
+	}
+}
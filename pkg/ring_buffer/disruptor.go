@@ -0,0 +1,308 @@
+package ringbuffer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Disruptor:
+Кольцо заранее выделенных слотов в стиле LMAX Disruptor
+Каждая группа потребителей видит каждое событие через свой курсор
+Потребители могут зависеть от курсоров других групп
+Стратегии ожидания: блокирующая, с уступкой процессора и активное ожидание
+*/
+
+// Sequence is a cursor padded to its own cache line to avoid false sharing.
+type Sequence struct {
+	_     [56]byte
+	value atomic.Int64
+	_     [56]byte
+}
+
+func newSequence() *Sequence {
+	s := &Sequence{}
+	s.value.Store(-1)
+	return s
+}
+
+func (s *Sequence) Get() int64 {
+	return s.value.Load()
+}
+
+// WaitStrategy decides how a consumer waits for a sequence to become
+// available, and how a producer waits for consumers to free a slot.
+type WaitStrategy interface {
+	// WaitFor returns the highest available sequence once it is >= seq,
+	// or earlier if halted reports true.
+	WaitFor(seq int64, available func() int64, halted func() bool) int64
+	// SignalAll is called whenever a producer publishes or a consumer advances.
+	SignalAll()
+}
+
+// BlockingWaitStrategy parks consumers on a condition variable. It is the
+// cheapest on CPU and has the highest latency.
+type BlockingWaitStrategy struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	waiters atomic.Int32
+}
+
+func NewBlockingWaitStrategy() *BlockingWaitStrategy {
+	s := &BlockingWaitStrategy{}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *BlockingWaitStrategy) WaitFor(seq int64, available func() int64, halted func() bool) int64 {
+	if a := available(); a >= seq {
+		return a
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.waiters.Add(1)
+	defer s.waiters.Add(-1)
+	for {
+		if a := available(); a >= seq || halted() {
+			return a
+		}
+		s.cond.Wait()
+	}
+}
+
+func (s *BlockingWaitStrategy) SignalAll() {
+	if s.waiters.Load() == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// YieldingWaitStrategy spins briefly and then yields the processor between checks.
+type YieldingWaitStrategy struct{}
+
+const yieldingSpinTries = 100
+
+func (YieldingWaitStrategy) WaitFor(seq int64, available func() int64, halted func() bool) int64 {
+	for i := 0; ; i++ {
+		if a := available(); a >= seq || halted() {
+			return a
+		}
+		if i >= yieldingSpinTries {
+			runtime.Gosched()
+		}
+	}
+}
+
+func (YieldingWaitStrategy) SignalAll() {}
+
+// BusySpinWaitStrategy never gives up the processor. Use it only when every
+// consumer has a dedicated core.
+type BusySpinWaitStrategy struct{}
+
+func (BusySpinWaitStrategy) WaitFor(seq int64, available func() int64, halted func() bool) int64 {
+	for {
+		if a := available(); a >= seq || halted() {
+			return a
+		}
+	}
+}
+
+func (BusySpinWaitStrategy) SignalAll() {}
+
+// EventHandler processes one event. endOfBatch is true for the last event
+// that was available when the batch started.
+type EventHandler[T any] func(seq int64, event *T, endOfBatch bool)
+
+// Consumer is a handler with its own cursor. It runs only over sequences
+// that have been published and processed by every consumer it depends on.
+type Consumer[T any] struct {
+	sequence  *Sequence
+	handler   EventHandler[T]
+	dependsOn []*Sequence
+}
+
+// Sequence returns the last sequence the consumer has processed.
+func (c *Consumer[T]) Sequence() int64 {
+	return c.sequence.Get()
+}
+
+// Disruptor is a multi-producer, multicast ring of preallocated slots.
+// Producers claim a sequence with Next, fill the slot returned by Slot and
+// Publish it. Every consumer sees every published event in order.
+type Disruptor[T any] struct {
+	slots     []T
+	mask      int64
+	shift     uint
+	cursor    *Sequence
+	published []atomic.Int64
+	wait      WaitStrategy
+
+	consumers []*Consumer[T]
+	gating    []*Sequence
+	halted    atomic.Bool
+	started   bool
+	wg        sync.WaitGroup
+}
+
+// NewDisruptor creates a ring with size rounded up to a power of two.
+func NewDisruptor[T any](size int, wait WaitStrategy) *Disruptor[T] {
+	n := 1
+	shift := uint(0)
+	for n < size {
+		n <<= 1
+		shift++
+	}
+	d := &Disruptor[T]{
+		slots:     make([]T, n),
+		mask:      int64(n - 1),
+		shift:     shift,
+		cursor:    newSequence(),
+		published: make([]atomic.Int64, n),
+		wait:      wait,
+	}
+	for i := range d.published {
+		d.published[i].Store(-1)
+	}
+	return d
+}
+
+// Handle registers a consumer that runs after the given consumers. It must
+// be called before Start.
+func (d *Disruptor[T]) Handle(handler EventHandler[T], after ...*Consumer[T]) *Consumer[T] {
+	if d.started {
+		panic("ringbuffer: Handle called after Start")
+	}
+	c := &Consumer[T]{sequence: newSequence(), handler: handler}
+	for _, dep := range after {
+		c.dependsOn = append(c.dependsOn, dep.sequence)
+	}
+	d.consumers = append(d.consumers, c)
+	d.gating = append(d.gating, c.sequence)
+	return c
+}
+
+// Start launches one goroutine per consumer.
+func (d *Disruptor[T]) Start() {
+	d.started = true
+	for _, c := range d.consumers {
+		d.wg.Add(1)
+		go d.runConsumer(c)
+	}
+}
+
+// Next claims the next sequence, waiting through the WaitStrategy while the
+// slowest consumer is a full ring behind.
+func (d *Disruptor[T]) Next() int64 {
+	for {
+		current := d.cursor.Get()
+		next := current + 1
+		wrap := next - int64(len(d.slots))
+		if wrap > d.minGating(current) {
+			// consumers call SignalAll as they advance, which wakes us
+			d.wait.WaitFor(wrap, func() int64 {
+				return d.minGating(current)
+			}, d.halted.Load)
+			continue
+		}
+		if d.cursor.value.CompareAndSwap(current, next) {
+			return next
+		}
+	}
+}
+
+// Slot returns the preallocated event for a claimed sequence.
+func (d *Disruptor[T]) Slot(seq int64) *T {
+	return &d.slots[seq&d.mask]
+}
+
+// Publish makes a claimed sequence visible to consumers.
+func (d *Disruptor[T]) Publish(seq int64) {
+	d.published[seq&d.mask].Store(seq >> d.shift)
+	d.wait.SignalAll()
+}
+
+// PublishEvent claims a slot, lets fill update it in place and publishes it.
+func (d *Disruptor[T]) PublishEvent(fill func(event *T)) int64 {
+	seq := d.Next()
+	fill(d.Slot(seq))
+	d.Publish(seq)
+	return seq
+}
+
+// Shutdown waits until every consumer has processed all claimed sequences
+// and stops the consumer goroutines. Producers must have stopped publishing.
+func (d *Disruptor[T]) Shutdown() {
+	last := d.cursor.Get()
+	for d.minGating(last) < last {
+		runtime.Gosched()
+	}
+	d.Halt()
+}
+
+// Halt stops the consumers without waiting for them to catch up.
+func (d *Disruptor[T]) Halt() {
+	d.halted.Store(true)
+	d.wait.SignalAll()
+	d.wg.Wait()
+}
+
+func (d *Disruptor[T]) runConsumer(c *Consumer[T]) {
+	defer d.wg.Done()
+	halted := d.halted.Load
+	next := c.sequence.Get() + 1
+	available := func() int64 {
+		return d.highestPublished(next, d.barrier(c))
+	}
+	for {
+		avail := d.wait.WaitFor(next, available, halted)
+		if avail < next {
+			if halted() {
+				return
+			}
+			continue
+		}
+		for seq := next; seq <= avail; seq++ {
+			c.handler(seq, &d.slots[seq&d.mask], seq == avail)
+		}
+		c.sequence.value.Store(avail)
+		d.wait.SignalAll()
+		next = avail + 1
+	}
+}
+
+// barrier returns the highest sequence c may read: the producer cursor,
+// or the slowest of the consumers it depends on.
+func (d *Disruptor[T]) barrier(c *Consumer[T]) int64 {
+	if len(c.dependsOn) == 0 {
+		return d.cursor.Get()
+	}
+	return minSequence(c.dependsOn, d.cursor.Get())
+}
+
+// highestPublished returns the last sequence in [low, high] before the
+// first gap left by a producer that has claimed but not yet published.
+func (d *Disruptor[T]) highestPublished(low, high int64) int64 {
+	for seq := low; seq <= high; seq++ {
+		if d.published[seq&d.mask].Load() != seq>>d.shift {
+			return seq - 1
+		}
+	}
+	return high
+}
+
+func (d *Disruptor[T]) minGating(def int64) int64 {
+	return minSequence(d.gating, def)
+}
+
+func minSequence(seqs []*Sequence, def int64) int64 {
+	m := def
+	for _, s := range seqs {
+		if v := s.Get(); v < m {
+			m = v
+		}
+	}
+	return m
+}
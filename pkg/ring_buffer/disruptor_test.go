@@ -0,0 +1,144 @@
+package ringbuffer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type testEvent struct {
+	value int64
+}
+
+func testDisruptorMulticast(t *testing.T, wait WaitStrategy) {
+	const producers = 2
+	const perProducer = 500
+
+	d := NewDisruptor[testEvent](16, wait)
+
+	var journalSum, replicationSum, businessSum atomic.Int64
+	var journalCount, replicationCount, businessCount atomic.Int64
+	journal := d.Handle(func(seq int64, ev *testEvent, _ bool) {
+		journalSum.Add(ev.value)
+		journalCount.Add(1)
+	})
+	replication := d.Handle(func(seq int64, ev *testEvent, _ bool) {
+		replicationSum.Add(ev.value)
+		replicationCount.Add(1)
+	})
+	var lastSeq int64 = -1
+	d.Handle(func(seq int64, ev *testEvent, _ bool) {
+		if seq != lastSeq+1 {
+			t.Errorf("expected sequence %d, got %d", lastSeq+1, seq)
+		}
+		lastSeq = seq
+		if journal.Sequence() < seq || replication.Sequence() < seq {
+			t.Errorf("business handler ran before its dependencies at %d", seq)
+		}
+		businessSum.Add(ev.value)
+		businessCount.Add(1)
+	}, journal, replication)
+	d.Start()
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= perProducer; i++ {
+				d.PublishEvent(func(ev *testEvent) {
+					ev.value = int64(i)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	d.Shutdown()
+
+	const total = producers * perProducer
+	const sum = producers * perProducer * (perProducer + 1) / 2
+	for name, got := range map[string][2]int64{
+		"journal":     {journalCount.Load(), journalSum.Load()},
+		"replication": {replicationCount.Load(), replicationSum.Load()},
+		"business":    {businessCount.Load(), businessSum.Load()},
+	} {
+		if got[0] != total || got[1] != sum {
+			t.Errorf("%s: expected %d events with sum %d, got %d with sum %d", name, total, sum, got[0], got[1])
+		}
+	}
+}
+
+func TestDisruptorBlocking(t *testing.T) {
+	testDisruptorMulticast(t, NewBlockingWaitStrategy())
+}
+
+func TestDisruptorYielding(t *testing.T) {
+	testDisruptorMulticast(t, YieldingWaitStrategy{})
+}
+
+func TestDisruptorBusySpin(t *testing.T) {
+	// Three spinning consumers and two producers need their own cores.
+	if runtime.GOMAXPROCS(0) < 5 {
+		t.Skip("busy-spin needs a core per goroutine")
+	}
+	testDisruptorMulticast(t, BusySpinWaitStrategy{})
+}
+
+// countingWaitStrategy records how often WaitFor is entered.
+type countingWaitStrategy struct {
+	WaitStrategy
+	waits atomic.Int64
+}
+
+func (s *countingWaitStrategy) WaitFor(seq int64, available func() int64, halted func() bool) int64 {
+	s.waits.Add(1)
+	return s.WaitStrategy.WaitFor(seq, available, halted)
+}
+
+func TestDisruptorProducerWaitsThroughStrategy(t *testing.T) {
+	wait := &countingWaitStrategy{WaitStrategy: NewBlockingWaitStrategy()}
+	d := NewDisruptor[testEvent](2, wait)
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var handled atomic.Int64
+	d.Handle(func(seq int64, ev *testEvent, _ bool) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		<-release
+		handled.Add(1)
+	})
+	d.Start()
+
+	for i := 0; i < 2; i++ {
+		d.PublishEvent(func(ev *testEvent) {})
+	}
+	// the consumer is stuck in the handler, so only the producer waits
+	<-entered
+	before := wait.waits.Load()
+	claimed := make(chan int64)
+	go func() {
+		claimed <- d.Next()
+	}()
+	for wait.waits.Load() == before {
+		runtime.Gosched()
+	}
+	select {
+	case seq := <-claimed:
+		t.Fatalf("claimed %d while the ring was full", seq)
+	default:
+	}
+
+	close(release)
+	seq := <-claimed
+	if seq != 2 {
+		t.Errorf("expected sequence 2, got %d", seq)
+	}
+	d.Publish(seq)
+	d.Shutdown()
+	if n := handled.Load(); n != 3 {
+		t.Errorf("expected 3 events handled, got %d", n)
+	}
+}
@@ -0,0 +1,458 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+Durable Ring Buffer:
+Хранит элементы в файле, поэтому очередь переживает перезапуск
+Каждая запись снабжена порядковым номером и контрольной суммой
+Курсоры чтения и записи сохраняются в заголовке файла
+При восстановлении оборванная запись отбрасывается
+*/
+
+/*
+File layout:
+
+	[header slot A][header slot B][data region of dataSize bytes]
+
+The two header slots are written alternately, so a torn header write
+always leaves the other slot intact. Records live in the data region as
+a ring and may wrap around its end:
+
+	[seq uint64][len uint32][crc uint32][payload]
+*/
+
+var (
+	// ErrRecordTooLarge is returned by Put when a record can never fit into the data region.
+	ErrRecordTooLarge = errors.New("record is larger than the buffer")
+	// ErrCorrupt is returned when neither header slot is valid or a stored record fails its checksum.
+	ErrCorrupt = errors.New("buffer file is corrupt")
+)
+
+const (
+	durableMagic      = "RBUF"
+	headerSlotSize    = 64
+	headerPayloadSize = 4 + 8*6
+	dataOffset        = 2 * headerSlotSize
+	recordHeaderSize  = 16
+
+	defaultDataSize = 1 << 20
+)
+
+// SyncPolicy controls when the file is fsynced.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every Put and Get.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs in the background every DurableOptions.SyncEvery.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+type DurableOptions struct {
+	// Size limits the number of queued records; zero means only DataSize applies.
+	Size int
+	// DataSize is the size of the data region in bytes. It is used only when
+	// the file is created; an existing file keeps its own size.
+	DataSize int64
+	Sync     SyncPolicy
+	// SyncEvery is the flush period for SyncInterval.
+	SyncEvery time.Duration
+}
+
+// DurableRingBuffer is a file-backed FIFO of []byte records with the same
+// blocking Put/Get behaviour as RingBuffer. Close releases the file at
+// once; CloseWrite lets Get drain the queue first, as RingBuffer.Close does.
+type DurableRingBuffer struct {
+	file *os.File
+	opts DurableOptions
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	// closed rejects Put; released is set once the file is closed, and
+	// releaseErr is what closing it returned.
+	closed     bool
+	released   bool
+	releaseErr error
+
+	dataSize int64
+	gen      uint64
+	readOff  int64
+	readSeq  uint64
+	writeOff int64
+	writeSeq uint64
+	used     int64
+	count    int
+	dirty    bool
+
+	stopSync chan struct{}
+}
+
+// OpenDurableRingBuffer opens or creates the buffer file at path. Records
+// from a previous run are recovered; a torn record at the end is discarded.
+func OpenDurableRingBuffer(path string, opts DurableOptions) (*DurableRingBuffer, error) {
+	if opts.DataSize <= 0 {
+		opts.DataSize = defaultDataSize
+	}
+	if opts.Sync == SyncInterval && opts.SyncEvery <= 0 {
+		opts.SyncEvery = time.Second
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	d := &DurableRingBuffer{file: file, opts: opts}
+	d.notEmpty = sync.NewCond(&d.mu)
+	d.notFull = sync.NewCond(&d.mu)
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		err = d.create()
+	} else {
+		err = d.recover()
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if opts.Sync == SyncInterval {
+		d.stopSync = make(chan struct{})
+		go d.syncLoop()
+	}
+	return d, nil
+}
+
+func (d *DurableRingBuffer) create() error {
+	d.dataSize = d.opts.DataSize
+	if err := d.file.Truncate(dataOffset + d.dataSize); err != nil {
+		return err
+	}
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	return d.file.Sync()
+}
+
+func (d *DurableRingBuffer) recover() error {
+	if err := d.readHeader(); err != nil {
+		return err
+	}
+
+	// Walk the records from the read cursor. The first record with a wrong
+	// sequence number, impossible length or bad checksum marks the end of
+	// the log; anything after it is a torn or stale write.
+	d.writeOff, d.writeSeq = d.readOff, d.readSeq
+	d.used, d.count = 0, 0
+	for {
+		_, n, err := d.readRecord(d.writeOff, d.writeSeq, d.dataSize-d.used)
+		if err == ErrCorrupt {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		d.writeOff = (d.writeOff + n) % d.dataSize
+		d.writeSeq++
+		d.used += n
+		d.count++
+	}
+
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	return d.file.Sync()
+}
+
+func (d *DurableRingBuffer) Put(item []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+
+	n := int64(recordHeaderSize + len(item))
+	if n > d.dataSize {
+		return ErrRecordTooLarge
+	}
+	for (d.opts.Size > 0 && d.count >= d.opts.Size) || d.used+n > d.dataSize {
+		if d.closed {
+			return ErrClosed
+		}
+		d.notFull.Wait()
+	}
+	if d.closed {
+		return ErrClosed
+	}
+
+	record := make([]byte, n)
+	binary.LittleEndian.PutUint64(record[0:], d.writeSeq)
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(item)))
+	copy(record[recordHeaderSize:], item)
+	binary.LittleEndian.PutUint32(record[12:], recordChecksum(record))
+	if err := d.writeData(d.writeOff, record); err != nil {
+		return err
+	}
+
+	d.writeOff = (d.writeOff + n) % d.dataSize
+	d.writeSeq++
+	d.used += n
+	d.count++
+	if err := d.commit(); err != nil {
+		return err
+	}
+	d.notEmpty.Signal()
+	return nil
+}
+
+// Get blocks until a record is available. After CloseWrite it keeps
+// returning the queued records and returns ErrClosed once they are drained;
+// after Close it returns ErrClosed right away.
+func (d *DurableRingBuffer) Get() ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for d.count == 0 && !d.closed {
+		d.notEmpty.Wait()
+	}
+	if d.released || d.count == 0 {
+		return nil, ErrClosed
+	}
+
+	item, n, err := d.readRecord(d.readOff, d.readSeq, d.used)
+	if err != nil {
+		return nil, err
+	}
+
+	d.readOff = (d.readOff + n) % d.dataSize
+	d.readSeq++
+	d.used -= n
+	d.count--
+	if err := d.commit(); err != nil {
+		return nil, err
+	}
+	d.notFull.Signal()
+	if d.closed && d.count == 0 {
+		// The item is already committed as read, so an error releasing
+		// the file is left for Close or CloseWrite to report.
+		d.releaseLocked()
+	}
+	return item, nil
+}
+
+func (d *DurableRingBuffer) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.count
+}
+
+// Close stops Put, wakes blocked callers and flushes and closes the file
+// right away. Records that were not read stay in the file for the next
+// OpenDurableRingBuffer. Use CloseWrite to drain the queue first.
+func (d *DurableRingBuffer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closeLocked()
+	d.releaseLocked()
+	return d.releaseErr
+}
+
+// CloseWrite stops Put and wakes blocked producers, but Get keeps draining
+// the queued records. The file is flushed and closed once the last one is
+// read, or right away if none is left; Close releases it early.
+func (d *DurableRingBuffer) CloseWrite() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closeLocked()
+	if d.count == 0 {
+		d.releaseLocked()
+	}
+	return d.releaseErr
+}
+
+func (d *DurableRingBuffer) closeLocked() {
+	d.closed = true
+	d.notEmpty.Broadcast()
+	d.notFull.Broadcast()
+}
+
+// releaseLocked flushes the cursors and closes the file once. Callers must
+// hold d.mu.
+func (d *DurableRingBuffer) releaseLocked() {
+	if d.released {
+		return
+	}
+	d.released = true
+	d.notEmpty.Broadcast()
+	if d.stopSync != nil {
+		close(d.stopSync)
+	}
+	err := d.file.Sync()
+	if cerr := d.file.Close(); err == nil {
+		err = cerr
+	}
+	d.releaseErr = err
+}
+
+// commit persists the cursors and applies the sync policy. Callers must hold d.mu.
+func (d *DurableRingBuffer) commit() error {
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	if d.opts.Sync == SyncAlways {
+		return d.file.Sync()
+	}
+	d.dirty = true
+	return nil
+}
+
+func (d *DurableRingBuffer) syncLoop() {
+	ticker := time.NewTicker(d.opts.SyncEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopSync:
+			return
+		case <-ticker.C:
+			d.mu.Lock()
+			// the file may have been released since the tick
+			if d.dirty && !d.released {
+				d.file.Sync()
+				d.dirty = false
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+// readRecord reads and verifies the record at off. It returns ErrCorrupt
+// if the record is not the one with sequence number seq or does not fit
+// into limit bytes.
+func (d *DurableRingBuffer) readRecord(off int64, seq uint64, limit int64) ([]byte, int64, error) {
+	if limit < recordHeaderSize {
+		return nil, 0, ErrCorrupt
+	}
+	header := make([]byte, recordHeaderSize)
+	if err := d.readData(off, header); err != nil {
+		return nil, 0, err
+	}
+	if binary.LittleEndian.Uint64(header[0:]) != seq {
+		return nil, 0, ErrCorrupt
+	}
+	n := recordHeaderSize + int64(binary.LittleEndian.Uint32(header[8:]))
+	if n > limit {
+		return nil, 0, ErrCorrupt
+	}
+
+	record := make([]byte, n)
+	copy(record, header)
+	if err := d.readData((off+recordHeaderSize)%d.dataSize, record[recordHeaderSize:]); err != nil {
+		return nil, 0, err
+	}
+	if binary.LittleEndian.Uint32(header[12:]) != recordChecksum(record) {
+		return nil, 0, ErrCorrupt
+	}
+	return record[recordHeaderSize:], n, nil
+}
+
+// recordChecksum covers the sequence number, length and payload.
+func recordChecksum(record []byte) uint32 {
+	crc := crc32.ChecksumIEEE(record[:12])
+	return crc32.Update(crc, crc32.IEEETable, record[recordHeaderSize:])
+}
+
+// writeData writes p at offset off of the data region, wrapping around its end.
+func (d *DurableRingBuffer) writeData(off int64, p []byte) error {
+	n := min(int64(len(p)), d.dataSize-off)
+	if _, err := d.file.WriteAt(p[:n], dataOffset+off); err != nil {
+		return err
+	}
+	if n < int64(len(p)) {
+		if _, err := d.file.WriteAt(p[n:], dataOffset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readData fills p from offset off of the data region, wrapping around its end.
+func (d *DurableRingBuffer) readData(off int64, p []byte) error {
+	n := min(int64(len(p)), d.dataSize-off)
+	if _, err := d.file.ReadAt(p[:n], dataOffset+off); err != nil {
+		return err
+	}
+	if n < int64(len(p)) {
+		if _, err := d.file.ReadAt(p[n:], dataOffset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DurableRingBuffer) writeHeader() error {
+	d.gen++
+	slot := make([]byte, headerSlotSize)
+	copy(slot, durableMagic)
+	binary.LittleEndian.PutUint64(slot[4:], d.gen)
+	binary.LittleEndian.PutUint64(slot[12:], uint64(d.dataSize))
+	binary.LittleEndian.PutUint64(slot[20:], uint64(d.readOff))
+	binary.LittleEndian.PutUint64(slot[28:], d.readSeq)
+	binary.LittleEndian.PutUint64(slot[36:], uint64(d.writeOff))
+	binary.LittleEndian.PutUint64(slot[44:], d.writeSeq)
+	binary.LittleEndian.PutUint32(slot[headerPayloadSize:], crc32.ChecksumIEEE(slot[:headerPayloadSize]))
+	_, err := d.file.WriteAt(slot, int64(d.gen%2)*headerSlotSize)
+	return err
+}
+
+// readHeader loads the newest valid header slot. The persisted write
+// cursor is only a hint; recover rebuilds it from the records.
+func (d *DurableRingBuffer) readHeader() error {
+	buf := make([]byte, dataOffset)
+	if _, err := d.file.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return err
+	}
+
+	found := false
+	for i := 0; i < 2; i++ {
+		slot := buf[i*headerSlotSize : (i+1)*headerSlotSize]
+		if string(slot[:4]) != durableMagic {
+			continue
+		}
+		if binary.LittleEndian.Uint32(slot[headerPayloadSize:]) != crc32.ChecksumIEEE(slot[:headerPayloadSize]) {
+			continue
+		}
+		gen := binary.LittleEndian.Uint64(slot[4:])
+		if found && gen < d.gen {
+			continue
+		}
+		found = true
+		d.gen = gen
+		d.dataSize = int64(binary.LittleEndian.Uint64(slot[12:]))
+		d.readOff = int64(binary.LittleEndian.Uint64(slot[20:]))
+		d.readSeq = binary.LittleEndian.Uint64(slot[28:])
+		d.writeOff = int64(binary.LittleEndian.Uint64(slot[36:]))
+		d.writeSeq = binary.LittleEndian.Uint64(slot[44:])
+	}
+	if !found || d.dataSize <= 0 || d.readOff < 0 || d.readOff >= d.dataSize {
+		return fmt.Errorf("%w: no valid header", ErrCorrupt)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDurableRingBufferReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.rb")
+	d, err := OpenDurableRingBuffer(path, DurableOptions{DataSize: 1024})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := d.Put([]byte(fmt.Sprintf("item-%d", i))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if item, _ := d.Get(); string(item) != "item-0" {
+		t.Errorf("expected item-0, got %q", item)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, err = OpenDurableRingBuffer(path, DurableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+	if d.Len() != 2 {
+		t.Fatalf("expected 2 records after reopen, got %d", d.Len())
+	}
+	for i := 1; i < 3; i++ {
+		item, err := d.Get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := fmt.Sprintf("item-%d", i); string(item) != want {
+			t.Errorf("expected %s, got %q", want, item)
+		}
+	}
+}
+
+func TestDurableRingBufferTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.rb")
+	d, err := OpenDurableRingBuffer(path, DurableOptions{DataSize: 1024, Sync: SyncNever})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = d.Put([]byte("first"))
+	_ = d.Put([]byte("second"))
+	_ = d.Put([]byte("third"))
+	d.Close()
+
+	// Damage the payload of the last record as a crash mid-write would.
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lastPayload := int64(dataOffset + 3*recordHeaderSize + len("first") + len("second"))
+	f.WriteAt([]byte("XX"), lastPayload)
+	f.Close()
+
+	d, err = OpenDurableRingBuffer(path, DurableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+	if d.Len() != 2 {
+		t.Fatalf("expected torn record to be dropped, got %d records", d.Len())
+	}
+	_ = d.Put([]byte("fourth"))
+	for _, want := range []string{"first", "second", "fourth"} {
+		item, err := d.Get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(item) != want {
+			t.Errorf("expected %s, got %q", want, item)
+		}
+	}
+}
+
+func TestDurableRingBufferWrapAndBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.rb")
+	d, err := OpenDurableRingBuffer(path, DurableOptions{Size: 2, DataSize: 100, Sync: SyncInterval, SyncEvery: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+
+	// Records of 16+10 bytes wrap around the 100 byte region several times.
+	for i := 0; i < 20; i++ {
+		item := []byte(fmt.Sprintf("record-%03d", i))
+		if err := d.Put(item); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := d.Get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(got) != string(item) {
+			t.Errorf("expected %s, got %q", item, got)
+		}
+	}
+
+	_ = d.Put([]byte("a"))
+	_ = d.Put([]byte("b"))
+	done := make(chan struct{})
+	go func() {
+		_ = d.Put([]byte("c"))
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expected blocking on full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, _ = d.Get()
+	<-done
+
+	if err := d.Put(make([]byte, 100)); err != ErrRecordTooLarge {
+		t.Errorf("expected ErrRecordTooLarge, got %v", err)
+	}
+}
+
+func TestDurableRingBufferDrainAfterCloseWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.rb")
+	d, err := OpenDurableRingBuffer(path, DurableOptions{DataSize: 1024, Sync: SyncInterval, SyncEvery: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = d.Put([]byte("a"))
+	_ = d.Put([]byte("b"))
+	if err := d.CloseWrite(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Put([]byte("c")); err != ErrClosed {
+		t.Errorf("expected ErrClosed from Put, got %v", err)
+	}
+	for _, want := range []string{"a", "b"} {
+		item, err := d.Get()
+		if err != nil || string(item) != want {
+			t.Fatalf("expected %s after CloseWrite, got %q, %v", want, item, err)
+		}
+	}
+	if _, err := d.Get(); err != ErrClosed {
+		t.Errorf("expected ErrClosed once drained, got %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Errorf("unexpected error releasing the drained file: %v", err)
+	}
+
+	d, err = OpenDurableRingBuffer(path, DurableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+	if d.Len() != 0 {
+		t.Errorf("expected drained records to stay consumed, got %d", d.Len())
+	}
+}
+
+func TestDurableRingBufferCloseWithQueuedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.rb")
+	d, err := OpenDurableRingBuffer(path, DurableOptions{DataSize: 1024, Sync: SyncInterval, SyncEvery: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = d.Put([]byte("a"))
+	_ = d.Put([]byte("b"))
+	if err := d.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := d.file.Stat(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected the file to be closed, got %v", err)
+	}
+	if _, err := d.Get(); err != ErrClosed {
+		t.Errorf("expected ErrClosed from Get after Close, got %v", err)
+	}
+
+	d, err = OpenDurableRingBuffer(path, DurableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+	if d.Len() != 2 {
+		t.Errorf("expected 2 records to survive Close, got %d", d.Len())
+	}
+}
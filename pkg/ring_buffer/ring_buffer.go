@@ -1,8 +1,10 @@
 package ringbuffer
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"sync"
+	"time"
 )
 
 /*
@@ -23,6 +25,9 @@ Ring Buffer Queue:
 - Возможность безопасного завершения работы
 */
 
+// ErrClosed is returned by Put after Close and by Get once a closed buffer is drained.
+var ErrClosed = errors.New("buffer is closed")
+
 type RingBuffer struct {
 	buffer   []interface{}
 	size     int
@@ -33,12 +38,37 @@ type RingBuffer struct {
 	notEmpty *sync.Cond
 	notFull  *sync.Cond
 	closed   bool
+
+	// Growable mode: size moves between minSize and maxSize.
+	// For a fixed buffer minSize == maxSize == size.
+	minSize     int
+	maxSize     int
+	shrinkAfter time.Duration
+	lowSince    time.Time
+
+	// stats is nil until EnableStats is called.
+	stats *ringStats
 }
 
 func NewRingBuffer(size int) *RingBuffer {
+	return NewGrowableRingBuffer(size, size, 0)
+}
+
+// NewGrowableRingBuffer creates a buffer that starts with initial slots and
+// doubles its capacity when full, up to maxSize. Put blocks only when the
+// buffer holds maxSize items. When occupancy stays at or below a quarter of the capacity
+// for shrinkAfter, the buffer halves its capacity, never going below initial.
+// Shrinking is checked on Put and Get, so an untouched buffer keeps its size.
+func NewGrowableRingBuffer(initial, maxSize int, shrinkAfter time.Duration) *RingBuffer {
+	if maxSize < initial {
+		maxSize = initial
+	}
 	rb := &RingBuffer{
-		buffer: make([]interface{}, size),
-		size:   size,
+		buffer:      make([]interface{}, initial),
+		size:        initial,
+		minSize:     initial,
+		maxSize:     maxSize,
+		shrinkAfter: shrinkAfter,
 	}
 	rb.notEmpty = sync.NewCond(&rb.mu)
 	rb.notFull = sync.NewCond(&rb.mu)
@@ -50,12 +80,20 @@ func (rb *RingBuffer) Put(item interface{}) error {
 	defer rb.mu.Unlock()
 
 	if rb.closed {
-		return fmt.Errorf("buffer is closed")
+		return ErrClosed
 	}
 
+	var waitStart time.Time
 	for rb.count == rb.size {
+		if rb.size < rb.maxSize {
+			rb.resize(min(max(rb.size*2, 1), rb.maxSize))
+			break
+		}
 		if rb.closed {
-			return fmt.Errorf("buffer is closed")
+			return ErrClosed
+		}
+		if rb.stats != nil && waitStart.IsZero() {
+			waitStart = time.Now()
 		}
 		rb.notFull.Wait()
 	}
@@ -63,17 +101,34 @@ func (rb *RingBuffer) Put(item interface{}) error {
 	rb.buffer[rb.writeIdx] = item
 	rb.writeIdx = (rb.writeIdx + 1) % rb.size
 	rb.count++
+	if rb.stats != nil {
+		rb.stats.recordPut(rb.count, waitStart)
+	}
+	rb.maybeShrink()
 	rb.notEmpty.Signal()
 	return nil
 }
 
 func (rb *RingBuffer) Get() (interface{}, error) {
+	return rb.get(context.Background())
+}
+
+// get is Get that gives up with ctx.Err() once ctx is done. Whoever cancels
+// ctx must broadcast notEmpty to wake a waiting caller.
+func (rb *RingBuffer) get(ctx context.Context) (interface{}, error) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 
+	var waitStart time.Time
 	for rb.count == 0 {
 		if rb.closed {
-			return nil, fmt.Errorf("buffer is closed")
+			return nil, ErrClosed
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if rb.stats != nil && waitStart.IsZero() {
+			waitStart = time.Now()
 		}
 		rb.notEmpty.Wait()
 	}
@@ -82,10 +137,50 @@ func (rb *RingBuffer) Get() (interface{}, error) {
 	rb.buffer[rb.readIdx] = nil
 	rb.readIdx = (rb.readIdx + 1) % rb.size
 	rb.count--
+	if rb.stats != nil {
+		rb.stats.recordGet(waitStart)
+	}
+	rb.maybeShrink()
 	rb.notFull.Signal()
 	return item, nil
 }
 
+// resize moves the items into a new slice of n slots, keeping FIFO order.
+// Callers must hold rb.mu and ensure n >= rb.count.
+func (rb *RingBuffer) resize(n int) {
+	buffer := make([]interface{}, n)
+	for i := 0; i < rb.count; i++ {
+		buffer[i] = rb.buffer[(rb.readIdx+i)%rb.size]
+	}
+	rb.buffer = buffer
+	rb.size = n
+	rb.readIdx = 0
+	rb.writeIdx = rb.count % n
+}
+
+// maybeShrink halves the capacity of a growable buffer once occupancy has
+// been low for shrinkAfter. Callers must hold rb.mu.
+func (rb *RingBuffer) maybeShrink() {
+	if rb.size <= rb.minSize {
+		rb.lowSince = time.Time{}
+		return
+	}
+	if rb.count > rb.size/4 {
+		rb.lowSince = time.Time{}
+		return
+	}
+	now := time.Now()
+	if rb.lowSince.IsZero() {
+		rb.lowSince = now
+		return
+	}
+	if now.Sub(rb.lowSince) < rb.shrinkAfter {
+		return
+	}
+	rb.resize(max(rb.size/2, rb.minSize))
+	rb.lowSince = now
+}
+
 func (rb *RingBuffer) Close() {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
@@ -99,3 +194,10 @@ func (rb *RingBuffer) Len() int {
 	defer rb.mu.Unlock()
 	return rb.count
 }
+
+// Cap returns the current capacity, which changes over time for a growable buffer.
+func (rb *RingBuffer) Cap() int {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.size
+}
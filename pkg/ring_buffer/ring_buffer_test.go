@@ -3,6 +3,7 @@ package ringbuffer
 import (
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestRingBufferBasic(t *testing.T) {
@@ -87,3 +88,64 @@ func TestRingBufferClose(t *testing.T) {
 		t.Errorf("expected error on closed buffer")
 	}
 }
+
+func TestRingBufferGrow(t *testing.T) {
+	rb := NewGrowableRingBuffer(2, 8, time.Hour)
+	// Wrap the read index before growing to check FIFO order survives resize.
+	_ = rb.Put(-1)
+	_, _ = rb.Get()
+	for i := 0; i < 8; i++ {
+		if err := rb.Put(i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if rb.Cap() != 8 {
+		t.Errorf("expected capacity 8, got %d", rb.Cap())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = rb.Put(8)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expected blocking at max capacity")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for i := 0; i <= 8; i++ {
+		item, err := rb.Get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if item != i {
+			t.Errorf("expected %d, got %v", i, item)
+		}
+	}
+	<-done
+}
+
+func TestRingBufferShrink(t *testing.T) {
+	rb := NewGrowableRingBuffer(2, 16, 10*time.Millisecond)
+	for i := 0; i < 16; i++ {
+		_ = rb.Put(i)
+	}
+	for i := 0; i < 16; i++ {
+		_, _ = rb.Get()
+	}
+	if rb.Cap() != 16 {
+		t.Errorf("expected capacity 16 before low-occupancy period, got %d", rb.Cap())
+	}
+
+	for i := 0; i < 10; i++ {
+		time.Sleep(15 * time.Millisecond)
+		_ = rb.Put(100 + i)
+		if item, _ := rb.Get(); item != 100+i {
+			t.Errorf("expected %d, got %v", 100+i, item)
+		}
+	}
+	if rb.Cap() != 2 {
+		t.Errorf("expected capacity to shrink back to 2, got %d", rb.Cap())
+	}
+}
@@ -0,0 +1,140 @@
+package ringbuffer
+
+import (
+	"math"
+	"time"
+)
+
+// histogramBounds are the upper bounds of the wait histogram buckets:
+// 1µs, 2µs, 4µs ... ~1s, plus a final bucket for everything slower.
+var histogramBounds = func() []time.Duration {
+	bounds := make([]time.Duration, 0, 22)
+	for d := time.Microsecond; d < 2*time.Second; d *= 2 {
+		bounds = append(bounds, d)
+	}
+	return append(bounds, time.Duration(math.MaxInt64))
+}()
+
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Stats is a snapshot of buffer activity since EnableStats was called.
+// Waits count the Put and Get calls that had to block on notFull or
+// notEmpty, and the wait times are the total time spent blocked.
+type Stats struct {
+	Len       int
+	Cap       int
+	HighWater int
+	Puts      uint64
+	Gets      uint64
+
+	PutWaits    uint64
+	PutWaitTime time.Duration
+	GetWaits    uint64
+	GetWaitTime time.Duration
+
+	// The histograms are nil unless sampling was requested.
+	PutWaitHistogram []HistogramBucket
+	GetWaitHistogram []HistogramBucket
+}
+
+type ringStats struct {
+	highWater int
+	puts      uint64
+	gets      uint64
+
+	putWaits    uint64
+	putWaitTime time.Duration
+	getWaits    uint64
+	getWaitTime time.Duration
+
+	sampleEvery int
+	putHist     []uint64
+	getHist     []uint64
+}
+
+// EnableStats starts collecting Stats. If sampleEvery is positive, every
+// sampleEvery-th wait is also recorded in a duration histogram. Until it
+// is called the only cost on Put and Get is a nil check.
+func (rb *RingBuffer) EnableStats(sampleEvery int) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	s := &ringStats{highWater: rb.count, sampleEvery: sampleEvery}
+	if sampleEvery > 0 {
+		s.putHist = make([]uint64, len(histogramBounds))
+		s.getHist = make([]uint64, len(histogramBounds))
+	}
+	rb.stats = s
+}
+
+func (rb *RingBuffer) Stats() Stats {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	st := Stats{Len: rb.count, Cap: rb.size}
+	s := rb.stats
+	if s == nil {
+		return st
+	}
+	st.HighWater = s.highWater
+	st.Puts = s.puts
+	st.Gets = s.gets
+	st.PutWaits = s.putWaits
+	st.PutWaitTime = s.putWaitTime
+	st.GetWaits = s.getWaits
+	st.GetWaitTime = s.getWaitTime
+	if s.sampleEvery > 0 {
+		st.PutWaitHistogram = histogramSnapshot(s.putHist)
+		st.GetWaitHistogram = histogramSnapshot(s.getHist)
+	}
+	return st
+}
+
+// recordPut is called with rb.mu held after an item is stored.
+// waitStart is zero if the Put did not block.
+func (s *ringStats) recordPut(count int, waitStart time.Time) {
+	s.puts++
+	if count > s.highWater {
+		s.highWater = count
+	}
+	if !waitStart.IsZero() {
+		s.putWaits++
+		d := time.Since(waitStart)
+		s.putWaitTime += d
+		s.sample(s.putHist, s.putWaits, d)
+	}
+}
+
+// recordGet is called with rb.mu held after an item is removed.
+func (s *ringStats) recordGet(waitStart time.Time) {
+	s.gets++
+	if !waitStart.IsZero() {
+		s.getWaits++
+		d := time.Since(waitStart)
+		s.getWaitTime += d
+		s.sample(s.getHist, s.getWaits, d)
+	}
+}
+
+func (s *ringStats) sample(hist []uint64, n uint64, d time.Duration) {
+	if s.sampleEvery <= 0 || n%uint64(s.sampleEvery) != 0 {
+		return
+	}
+	for i, bound := range histogramBounds {
+		if d <= bound {
+			hist[i]++
+			return
+		}
+	}
+}
+
+func histogramSnapshot(hist []uint64) []HistogramBucket {
+	buckets := make([]HistogramBucket, len(hist))
+	for i, c := range hist {
+		buckets[i] = HistogramBucket{UpperBound: histogramBounds[i], Count: c}
+	}
+	return buckets
+}
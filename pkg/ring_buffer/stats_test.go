@@ -0,0 +1,63 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBufferStats(t *testing.T) {
+	rb := NewRingBuffer(2)
+	if st := rb.Stats(); st.Puts != 0 || st.Cap != 2 {
+		t.Errorf("unexpected stats before EnableStats: %+v", st)
+	}
+	rb.EnableStats(1)
+
+	_ = rb.Put(1)
+	_ = rb.Put(2)
+	done := make(chan struct{})
+	go func() {
+		_ = rb.Put(3)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	_, _ = rb.Get()
+	<-done
+	_, _ = rb.Get()
+	_, _ = rb.Get()
+
+	st := rb.Stats()
+	if st.Len != 0 || st.HighWater != 2 || st.Puts != 3 || st.Gets != 3 {
+		t.Errorf("unexpected counters: %+v", st)
+	}
+	if st.PutWaits != 1 || st.PutWaitTime < 10*time.Millisecond {
+		t.Errorf("expected one producer wait of at least 10ms, got %d waits, %v", st.PutWaits, st.PutWaitTime)
+	}
+
+	var sampled uint64
+	for _, b := range st.PutWaitHistogram {
+		sampled += b.Count
+	}
+	if sampled != 1 {
+		t.Errorf("expected 1 sampled producer wait, got %d", sampled)
+	}
+}
+
+func BenchmarkRingBufferPutGet(b *testing.B) {
+	for _, bc := range []struct {
+		name  string
+		setup func(rb *RingBuffer)
+	}{
+		{"disabled", func(*RingBuffer) {}},
+		{"enabled", func(rb *RingBuffer) { rb.EnableStats(0) }},
+		{"histogram", func(rb *RingBuffer) { rb.EnableStats(16) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			rb := NewRingBuffer(64)
+			bc.setup(rb)
+			for i := 0; i < b.N; i++ {
+				_ = rb.Put(i)
+				_, _ = rb.Get()
+			}
+		})
+	}
+}
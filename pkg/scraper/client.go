@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+)
+
+// newClient returns the client described by opts. Without any client
+// options it is http.DefaultClient, as before options existed.
+func newClient(opts Options) *http.Client {
+	if opts.Client == nil && opts.Transport == nil && opts.Jar == nil {
+		return http.DefaultClient
+	}
+	client := &http.Client{}
+	if opts.Client != nil {
+		// copy, so that the caller's client is not modified
+		*client = *opts.Client
+	}
+	if opts.Transport != nil {
+		client.Transport = opts.Transport
+	}
+	if opts.Jar != nil {
+		client.Jar = opts.Jar
+	}
+	return client
+}
+
+// newRequest builds a GET request for rawURL with the default headers and
+// user agent, then hands it to ModifyRequest.
+func (s *Scraper) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range s.opts.Header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if s.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", s.opts.UserAgent)
+	}
+	if s.opts.ModifyRequest != nil {
+		if err := s.opts.ModifyRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
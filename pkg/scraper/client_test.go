@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScraperHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent() + "|" + r.Header.Get("Accept-Language") + "|" + r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	errNoToken := errors.New("no token")
+	s := NewScraper(Options{
+		UserAgent: "testbot/1.0",
+		Header:    http.Header{"Accept-Language": {"en"}, "User-Agent": {"ignored"}},
+		ModifyRequest: func(req *http.Request) error {
+			if req.URL.Path == "/fail" {
+				return errNoToken
+			}
+			req.Header.Set("Authorization", "Bearer token")
+			return nil
+		},
+	})
+	results := s.Scrape(context.Background(), []string{srv.URL + "/ok", srv.URL + "/fail"})
+	if got := results[0].Content; got != "testbot/1.0|en|Bearer token" {
+		t.Errorf("unexpected headers %q", got)
+	}
+	if !errors.Is(results[1].Error, errNoToken) {
+		t.Errorf("expected the ModifyRequest error, got %v", results[1].Error)
+	}
+}
+
+func TestScraperCookieJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "42"})
+			return
+		}
+		if c, err := r.Cookie("session"); err == nil {
+			w.Write([]byte(c.Value))
+		}
+	}))
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{}
+	s := NewScraper(Options{MaxConcurrent: 1, Client: client, Jar: jar})
+	results := s.Scrape(context.Background(), []string{srv.URL + "/login", srv.URL + "/page"})
+	if got := results[1].Content; got != "42" {
+		t.Errorf("expected the session cookie to be sent, got %q", got)
+	}
+	if client.Jar != nil {
+		t.Error("the caller's client was modified")
+	}
+}
+
+type countingTransport struct {
+	n atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestScraperTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	transport := &countingTransport{}
+	s := NewScraper(Options{Transport: transport, Robots: true})
+	s.Scrape(context.Background(), []string{srv.URL + "/a", srv.URL + "/b"})
+	// robots.txt and both pages
+	if n := transport.n.Load(); n != 3 {
+		t.Errorf("expected 3 requests through the transport, got %d", n)
+	}
+	if NewScraper(Options{}).client != http.DefaultClient {
+		t.Error("expected http.DefaultClient without client options")
+	}
+}
@@ -0,0 +1,262 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// CrawlOptions configures Crawl.
+type CrawlOptions struct {
+	// MaxDepth is how many links away from the seeds to follow; 0 only
+	// fetches the seeds.
+	MaxDepth int
+	// MaxPages bounds the number of pages fetched; 0 means no limit.
+	MaxPages int
+	Scope    Scope
+}
+
+// Scope restricts the links a crawl follows. Seeds are always fetched. A
+// link must pass every rule that is set.
+type Scope struct {
+	// SameHost only follows links to the hosts of the seeds.
+	SameHost bool
+	// Domains only follows links to these domains and their subdomains.
+	Domains []string
+	// Allow, if not empty, only follows URLs matching one of the patterns;
+	// Deny never follows URLs matching any of them. Patterns are matched
+	// against the normalized URL.
+	Allow []*regexp.Regexp
+	Deny  []*regexp.Regexp
+}
+
+// CrawlResult is a fetched page and its distance from the seeds.
+type CrawlResult struct {
+	ScrapResult
+	Depth int
+}
+
+var errNotHTTP = errors.New("not an http or https URL")
+
+// Crawl fetches the seeds and follows the links in their pages breadth
+// first, each URL once. Every level is fetched with ScrapeStream, so the
+// scraper's concurrency, host limits and robots.txt apply. The channel is
+// closed when the crawl is done or ctx is done.
+func (s *Scraper) Crawl(ctx context.Context, seeds []string, opts CrawlOptions) <-chan CrawlResult {
+	out := make(chan CrawlResult)
+	go func() {
+		defer close(out)
+
+		seen := make(map[string]bool)
+		seedHosts := make(map[string]bool)
+		var level []string
+		for _, seed := range seeds {
+			u, err := normalizeURL(nil, seed)
+			if err != nil {
+				// let the scraper report the bad URL
+				level = append(level, seed)
+				continue
+			}
+			if !seen[u.String()] {
+				seen[u.String()] = true
+				seedHosts[u.Host] = true
+				level = append(level, u.String())
+			}
+		}
+
+		// fetched holds where pages ended up after redirects. A link to one
+		// of them queued before the redirect was seen is dropped when its
+		// level starts, so the page is not fetched twice.
+		fetched := make(map[string]bool)
+		pages := 0
+		for depth := 0; len(level) > 0 && depth <= opts.MaxDepth; depth++ {
+			level = slices.DeleteFunc(level, func(u string) bool { return fetched[u] })
+			if opts.MaxPages > 0 {
+				level = level[:min(len(level), opts.MaxPages-pages)]
+			}
+			var next []string
+			for result := range s.ScrapeStream(ctx, level) {
+				pages++
+				if final, err := normalizeURL(nil, result.FinalURL); err == nil {
+					seen[final.String()] = true
+					fetched[final.String()] = true
+				}
+				select {
+				case out <- CrawlResult{ScrapResult: result, Depth: depth}:
+				case <-ctx.Done():
+					return
+				}
+				if result.Error != nil || depth == opts.MaxDepth || !isHTML(result.ContentType) {
+					continue
+				}
+				// relative links are relative to where redirects ended
+				pageURL := result.FinalURL
+				if pageURL == "" {
+					pageURL = result.URL
+				}
+				for _, link := range extractLinks(pageURL, result.Content) {
+					if !seen[link.String()] && opts.Scope.allows(link, seedHosts) {
+						seen[link.String()] = true
+						next = append(next, link.String())
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			level = next
+		}
+	}()
+	return out
+}
+
+// isHTML reports whether a page may contain links. Pages without a
+// Content-Type are given the benefit of the doubt.
+func isHTML(contentType string) bool {
+	return contentType == "" || contentType == "text/html" || contentType == "application/xhtml+xml"
+}
+
+// extractLinks returns the normalized targets of the <a href> links in
+// content, resolved against pageURL or the page's first <base href>. The
+// tokenizer leaves out comments and the text of <script>, <style> and the
+// like; links inside <template> are inert and skipped too.
+func extractLinks(pageURL, content string) []*url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var hrefs []string
+	baseSeen := false
+	inTemplate := 0
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken && tt != html.EndTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		switch atom.Lookup(name) {
+		case atom.Template:
+			if tt == html.StartTagToken {
+				inTemplate++
+			} else if tt == html.EndTagToken && inTemplate > 0 {
+				inTemplate--
+			}
+			continue
+		case atom.A, atom.Base:
+		default:
+			continue
+		}
+		if tt == html.EndTagToken || inTemplate > 0 || !hasAttr {
+			continue
+		}
+		href, ok := tagAttr(z, "href")
+		if !ok {
+			continue
+		}
+		if atom.Lookup(name) == atom.A {
+			hrefs = append(hrefs, href)
+		} else if !baseSeen {
+			baseSeen = true
+			if b, err := base.Parse(strings.TrimSpace(href)); err == nil {
+				base = b
+			}
+		}
+	}
+
+	var links []*url.URL
+	for _, href := range hrefs {
+		if u, err := normalizeURL(base, strings.TrimSpace(href)); err == nil {
+			links = append(links, u)
+		}
+	}
+	return links
+}
+
+// tagAttr returns the value of the named attribute of the current tag,
+// with entities already decoded by the tokenizer.
+func tagAttr(z *html.Tokenizer, name string) (string, bool) {
+	for {
+		key, value, more := z.TagAttr()
+		if string(key) == name {
+			return string(value), true
+		}
+		if !more {
+			return "", false
+		}
+	}
+}
+
+// normalizeURL resolves ref against base, if given, and normalizes it so
+// that equal pages compare equal: lower-case scheme and host, no default
+// port, no fragment and a path of at least "/". Only http and https URLs
+// are accepted.
+func normalizeURL(base *url.URL, ref string) (*url.URL, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &url.Error{Op: "normalize", URL: ref, Err: errNotHTTP}
+	}
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+	return u, nil
+}
+
+func (sc Scope) allows(u *url.URL, seedHosts map[string]bool) bool {
+	if sc.SameHost && !seedHosts[u.Host] {
+		return false
+	}
+	if len(sc.Domains) > 0 {
+		host := u.Hostname()
+		ok := false
+		for _, d := range sc.Domains {
+			d = strings.ToLower(strings.TrimPrefix(d, "."))
+			if host == d || strings.HasSuffix(host, "."+d) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	s := u.String()
+	for _, re := range sc.Deny {
+		if re.MatchString(s) {
+			return false
+		}
+	}
+	if len(sc.Allow) == 0 {
+		return true
+	}
+	for _, re := range sc.Allow {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// newSite serves a small site. Every page links back to the index, so the
+// crawl has to deduplicate.
+func newSite(t *testing.T, external string) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/": `<a href="/docs/">Docs</a> <a href='blog'>Blog</a> <a href="` + external + `/">out</a>` +
+			`<!-- <a href="/in-comment">old</a> --><script>document.write('<a href="/in-script">')</script>` +
+			`<template><a href="/in-template">t</a></template><textarea><a href="/in-textarea"></textarea>`,
+		"/docs/":               `<a href="intro.html#top">Intro</a> <a href="./api.html">API</a> <a href="/">home</a>`,
+		"/docs/intro":          `not reached`,
+		"/docs/intro.html":     `<base href="/docs/deep/"><a href="page.html">Deep</a> <a HREF=/>home</a>`,
+		"/docs/api.html":       `<a href="mailto:a@example.com">mail</a> <a href="/private/x">x</a>`,
+		"/docs/deep/page.html": `<a href="/">home</a>`,
+		"/blog":                `<a href="/?utm=1">home again</a>`,
+		"/private/x":           `secret`,
+		"/?utm=1":              `home with query`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func crawlPaths(t *testing.T, srv *httptest.Server, opts CrawlOptions) map[string]int {
+	t.Helper()
+	s := NewScraper(Options{MaxConcurrent: 4})
+	depths := make(map[string]int)
+	for result := range s.Crawl(context.Background(), []string{srv.URL}, opts) {
+		if result.Error != nil {
+			t.Errorf("unexpected error for %s: %v", result.URL, result.Error)
+		}
+		u, _ := url.Parse(result.URL)
+		path := u.RequestURI()
+		if _, dup := depths[path]; dup && u.Host == srv.Listener.Addr().String() {
+			t.Errorf("%s fetched twice", path)
+		}
+		depths[path] = result.Depth
+	}
+	return depths
+}
+
+func TestCrawl(t *testing.T) {
+	external := newSite(t, "")
+	srv := newSite(t, external.URL)
+
+	depths := crawlPaths(t, srv, CrawlOptions{
+		MaxDepth: 3,
+		Scope: Scope{
+			SameHost: true,
+			Deny:     []*regexp.Regexp{regexp.MustCompile(`/private/`)},
+		},
+	})
+	want := map[string]int{
+		"/":                    0,
+		"/docs/":               1,
+		"/blog":                1,
+		"/docs/intro.html":     2,
+		"/docs/api.html":       2,
+		"/?utm=1":              2,
+		"/docs/deep/page.html": 3,
+	}
+	if len(depths) != len(want) {
+		t.Errorf("expected %d pages, got %v", len(want), depths)
+	}
+	for path, depth := range want {
+		if got, ok := depths[path]; !ok || got != depth {
+			t.Errorf("%s: expected depth %d, got %d (fetched %v)", path, depth, got, ok)
+		}
+	}
+}
+
+func TestCrawlLimits(t *testing.T) {
+	srv := newSite(t, "http://other.invalid")
+
+	depths := crawlPaths(t, srv, CrawlOptions{MaxDepth: 1, Scope: Scope{SameHost: true}})
+	if len(depths) != 3 {
+		t.Errorf("expected the index and two links at depth 1, got %v", depths)
+	}
+
+	depths = crawlPaths(t, srv, CrawlOptions{MaxDepth: 5, MaxPages: 4, Scope: Scope{SameHost: true}})
+	if len(depths) != 4 {
+		t.Errorf("expected 4 pages, got %v", depths)
+	}
+
+	depths = crawlPaths(t, srv, CrawlOptions{
+		MaxDepth: 5,
+		Scope:    Scope{Allow: []*regexp.Regexp{regexp.MustCompile(`/docs/([a-z]+\.html)?$`)}},
+	})
+	if !slices.Equal(sortedKeys(depths), []string{"/", "/docs/", "/docs/api.html", "/docs/intro.html"}) {
+		t.Errorf("expected only the seed and matching pages, got %v", depths)
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestNormalizeURL(t *testing.T) {
+	base, _ := url.Parse("https://Example.com/a/b/c.html")
+	tests := []struct {
+		ref, want string
+	}{
+		{"../d", "https://example.com/a/d"},
+		{"HTTP://Example.COM:80", "http://example.com/"},
+		{"https://example.com:443/x#frag", "https://example.com/x"},
+		{"?q=1", "https://example.com/a/b/c.html?q=1"},
+		{"//cdn.example.com/x", "https://cdn.example.com/x"},
+		{"javascript:void(0)", ""},
+		{"mailto:a@example.com", ""},
+	}
+	for _, tt := range tests {
+		u, err := normalizeURL(base, tt.ref)
+		got := ""
+		if err == nil {
+			got = u.String()
+		}
+		if got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, expected %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestScopeDomains(t *testing.T) {
+	sc := Scope{Domains: []string{"example.com"}}
+	for host, want := range map[string]bool{
+		"example.com":      true,
+		"docs.example.com": true,
+		"badexample.com":   false,
+		"example.org":      false,
+	} {
+		if got := sc.allows(&url.URL{Scheme: "https", Host: host, Path: "/"}, nil); got != want {
+			t.Errorf("%s: expected %v, got %v", host, want, got)
+		}
+	}
+}
+
+func TestCrawlSkipsNonHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, `<a href="/other">not a link</a>`)
+	}))
+	defer srv.Close()
+
+	if depths := crawlPaths(t, srv, CrawlOptions{MaxDepth: 2}); len(depths) != 1 {
+		t.Errorf("expected only the seed, got %v", depths)
+	}
+}
+
+func TestCrawlRedirectSeen(t *testing.T) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/old">old</a> <a href="/a">a</a>`)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<a href="/new">new</a>`)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := NewScraper(Options{})
+	for range s.Crawl(context.Background(), []string{srv.URL}, CrawlOptions{MaxDepth: 3}) {
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("expected /new to be fetched once, got %d", n)
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	page := `<html><head><base href="/root/"><base href="/ignored/"></head><body>
+<!-- <a href="/commented">x</a> -->
+<a href="a?x=1&amp;y=2">entity</a>
+<A HREF = 'b' >upper case</A>
+<a name="no-href">anchor</a>
+<style>a[href="/in-style"] {}</style>
+<template><a href="/in-template">t</a></template>
+<a href="/after-template">after</a>
+</body></html>`
+	var got []string
+	for _, u := range extractLinks("http://example.com/page", page) {
+		got = append(got, u.String())
+	}
+	want := []string{
+		"http://example.com/root/a?x=1&y=2",
+		"http://example.com/root/b",
+		"http://example.com/after-template",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
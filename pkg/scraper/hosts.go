@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimits are applied to every host separately. Zero values disable
+// the corresponding limit.
+type HostLimits struct {
+	// Rate is the sustained number of requests per second, with bursts of
+	// up to Burst requests (at least one).
+	Rate  float64
+	Burst int
+	// MaxConcurrent bounds the requests in flight to the host within one
+	// call to Scrape or ScrapeStream.
+	MaxConcurrent int
+	// CrawlDelay is the minimum time between the starts of two requests.
+	CrawlDelay time.Duration
+}
+
+// hostQueue is the dispatcher's view of one host during a run.
+type hostQueue struct {
+	host    string
+	queue   []int
+	active  int
+	limiter *hostLimiter
+	// loading is set while robots.txt is fetched.
+	loading bool
+}
+
+// hostLimiter is a token bucket combined with a crawl delay. It is shared
+// by all calls on a Scraper, so that back-to-back calls respect the rate.
+type hostLimiter struct {
+	limits HostLimits
+
+	mu        sync.Mutex
+	tokens    float64
+	refilled  time.Time
+	lastStart time.Time
+}
+
+// reserve takes a token and returns 0 if a request may start now, and
+// otherwise how long to wait before asking again.
+func (l *hostLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	if rate := l.limits.Rate; rate > 0 {
+		burst := float64(max(l.limits.Burst, 1))
+		if l.refilled.IsZero() {
+			l.tokens = burst
+		} else {
+			l.tokens = min(burst, l.tokens+now.Sub(l.refilled).Seconds()*rate)
+		}
+		l.refilled = now
+		if l.tokens < 1 {
+			wait = time.Duration((1 - l.tokens) / rate * float64(time.Second))
+		}
+	}
+	if !l.lastStart.IsZero() {
+		wait = max(wait, l.lastStart.Add(l.limits.CrawlDelay).Sub(now))
+	}
+	if wait > 0 {
+		return wait
+	}
+	if l.limits.Rate > 0 {
+		l.tokens--
+	}
+	l.lastStart = now
+	return 0
+}
+
+// raiseCrawlDelay applies a Crawl-delay from robots.txt if it is longer
+// than the configured one.
+func (l *hostLimiter) raiseCrawlDelay(d time.Duration) {
+	l.mu.Lock()
+	l.limits.CrawlDelay = max(l.limits.CrawlDelay, d)
+	l.mu.Unlock()
+}
+
+// limiter returns the limiter of host, creating it on first use.
+func (s *Scraper) limiter(host string) *hostLimiter {
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+	l, ok := s.limiters[host]
+	if !ok {
+		if s.limiters == nil {
+			s.limiters = make(map[string]*hostLimiter)
+		}
+		l = &hostLimiter{limits: s.opts.Host}
+		s.limiters[host] = l
+	}
+	return l
+}
+
+// pickHost returns the first host from start on, wrapping around, that
+// may start a request now. Otherwise it returns how long until the
+// soonest rate-limited host is ready, or 0 if every host is waiting for
+// a request to finish.
+func (s *Scraper) pickHost(hosts []*hostQueue, start int) (*hostQueue, time.Duration) {
+	var wait time.Duration
+	now := time.Now()
+	for k := range hosts {
+		hq := hosts[(start+k)%len(hosts)]
+		if len(hq.queue) == 0 || hq.loading {
+			continue
+		}
+		if limit := s.opts.Host.MaxConcurrent; limit > 0 && hq.active >= limit {
+			continue
+		}
+		if hq.limiter == nil {
+			return hq, 0
+		}
+		d := hq.limiter.reserve(now)
+		if d == 0 {
+			return hq, 0
+		}
+		if wait == 0 || d < wait {
+			wait = d
+		}
+	}
+	return nil, wait
+}
+
+// waitHost blocks until the host of u may take another request, for
+// retries.
+func (s *Scraper) waitHost(ctx context.Context, u *url.URL) bool {
+	l := s.limiter(strings.ToLower(u.Host))
+	for {
+		d := l.reserve(time.Now())
+		if d == 0 {
+			return true
+		}
+		if !sleep(ctx, d) {
+			return false
+		}
+	}
+}
+
+// hostOf returns the lower-cased host and port of rawURL, or "" if it
+// does not parse.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
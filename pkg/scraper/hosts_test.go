@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hostServer records the peak number of concurrent requests and the
+// start time of each request.
+type hostServer struct {
+	*httptest.Server
+	inflight atomic.Int32
+	peak     atomic.Int32
+
+	mu     sync.Mutex
+	starts []time.Time
+}
+
+func newHostServer(t *testing.T, delay time.Duration) *hostServer {
+	t.Helper()
+	hs := &hostServer{}
+	hs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hs.mu.Lock()
+		hs.starts = append(hs.starts, time.Now())
+		hs.mu.Unlock()
+		n := hs.inflight.Add(1)
+		for p := hs.peak.Load(); n > p && !hs.peak.CompareAndSwap(p, n); p = hs.peak.Load() {
+		}
+		time.Sleep(delay)
+		hs.inflight.Add(-1)
+	}))
+	t.Cleanup(hs.Close)
+	return hs
+}
+
+func (hs *hostServer) urls(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/%d", hs.URL, i)
+	}
+	return urls
+}
+
+// minGap returns the smallest time between two request starts.
+func (hs *hostServer) minGap() time.Duration {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	gap := time.Duration(1<<63 - 1)
+	for i := 1; i < len(hs.starts); i++ {
+		gap = min(gap, hs.starts[i].Sub(hs.starts[i-1]))
+	}
+	return gap
+}
+
+func TestHostConcurrency(t *testing.T) {
+	busy := newHostServer(t, 20*time.Millisecond)
+	other := newHostServer(t, 20*time.Millisecond)
+
+	// The busy host comes first, but must not hold up the other one.
+	urls := append(busy.urls(10), other.urls(10)...)
+	s := NewScraper(Options{MaxConcurrent: 8, Host: HostLimits{MaxConcurrent: 2}})
+	start := time.Now()
+	for _, result := range s.Scrape(context.Background(), urls) {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+	}
+	elapsed := time.Since(start)
+
+	if p := busy.peak.Load(); p != 2 {
+		t.Errorf("expected a peak of 2 requests to the busy host, got %d", p)
+	}
+	if p := other.peak.Load(); p != 2 {
+		t.Errorf("expected a peak of 2 requests to the other host, got %d", p)
+	}
+	// Five rounds of two requests per host, both hosts in parallel.
+	if elapsed > 180*time.Millisecond {
+		t.Errorf("hosts blocked each other: took %v", elapsed)
+	}
+}
+
+func TestHostRateAndCrawlDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits HostLimits
+		gap    time.Duration
+	}{
+		// Gaps are measured on the server, so allow for some scheduling noise.
+		{"rate", HostLimits{Rate: 20}, 40 * time.Millisecond},
+		{"crawl delay", HostLimits{CrawlDelay: 50 * time.Millisecond}, 40 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited := newHostServer(t, 0)
+			free := newHostServer(t, 0)
+			s := NewScraper(Options{MaxConcurrent: 4, Host: tt.limits})
+
+			results := s.Scrape(context.Background(), append(limited.urls(5), free.urls(1)...))
+			for _, result := range results {
+				if result.Error != nil {
+					t.Fatalf("unexpected error: %v", result.Error)
+				}
+			}
+			if gap := limited.minGap(); gap < tt.gap {
+				t.Errorf("expected requests at least %v apart, got %v", tt.gap, gap)
+			}
+
+			// The limiter remembers the host across calls.
+			s.Scrape(context.Background(), limited.urls(1))
+			if gap := limited.minGap(); gap < tt.gap {
+				t.Errorf("expected requests at least %v apart across calls, got %v", tt.gap, gap)
+			}
+		})
+	}
+}
+
+func TestHostBurst(t *testing.T) {
+	hs := newHostServer(t, 0)
+	s := NewScraper(Options{MaxConcurrent: 4, Host: HostLimits{Rate: 5, Burst: 3}})
+	start := time.Now()
+	s.Scrape(context.Background(), hs.urls(4))
+	// Three requests go out at once and the fourth waits for a token.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the fourth request to wait, took %v", elapsed)
+	}
+}
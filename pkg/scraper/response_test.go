@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newResponseServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Test", "yes")
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("<p>hello</p>"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		// no Content-Length, so the limit is found while reading
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("x", 1000)))
+	})
+	mux.HandleFunc("/sized", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 1000)))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScrapResultMetadata(t *testing.T) {
+	srv := newResponseServer(t)
+	results := NewScraper(Options{}).Scrape(context.Background(), []string{srv.URL + "/redirect", srv.URL + "/missing"})
+
+	r := results[0]
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.StatusCode != http.StatusOK || r.FinalURL != srv.URL+"/page" {
+		t.Errorf("unexpected status %d and final URL %q", r.StatusCode, r.FinalURL)
+	}
+	if r.ContentType != "text/html" || r.Header.Get("X-Test") != "yes" {
+		t.Errorf("unexpected content type %q and header %v", r.ContentType, r.Header)
+	}
+	if r.Bytes != int64(len("<p>hello</p>")) || r.Elapsed < 10*time.Millisecond {
+		t.Errorf("unexpected byte count %d and elapsed time %v", r.Bytes, r.Elapsed)
+	}
+
+	// Without ErrorOnNon2xx a 404 is not an error, but is visible.
+	if results[1].Error != nil || results[1].StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected 404 result %+v", results[1])
+	}
+}
+
+func TestErrorOnNon2xx(t *testing.T) {
+	srv := newResponseServer(t)
+	s := NewScraper(Options{ErrorOnNon2xx: true, Retry: RetryPolicy{MaxAttempts: 3}})
+	r := s.Scrape(context.Background(), []string{srv.URL + "/missing"})[0]
+
+	var statusErr *StatusError
+	if !errors.As(r.Error, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 StatusError, got %v", r.Error)
+	}
+	if statusErr.Error() != "unexpected status 404 Not Found" {
+		t.Errorf("unexpected message %q", statusErr.Error())
+	}
+	if r.Attempts != 1 || r.Content == "" {
+		t.Errorf("expected one attempt that kept the body, got %d attempts and %q", r.Attempts, r.Content)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	srv := newResponseServer(t)
+	urls := []string{srv.URL + "/big", srv.URL + "/sized", srv.URL + "/page"}
+
+	results := NewScraper(Options{MaxBodySize: 100}).Scrape(context.Background(), urls)
+	for _, r := range results[:2] {
+		if !errors.Is(r.Error, ErrBodyTooLarge) {
+			t.Errorf("%s: expected ErrBodyTooLarge, got %v", r.URL, r.Error)
+		}
+	}
+	if results[2].Error != nil {
+		t.Errorf("unexpected error for a small body: %v", results[2].Error)
+	}
+
+	results = NewScraper(Options{MaxBodySize: 100, TruncateBody: true}).Scrape(context.Background(), urls)
+	for _, r := range results[:2] {
+		if r.Error != nil || len(r.Content) != 100 || r.Bytes != 100 || !r.Truncated {
+			t.Errorf("%s: expected a truncated body, got %d bytes, error %v", r.URL, len(r.Content), r.Error)
+		}
+	}
+	if results[2].Truncated {
+		t.Error("small body marked as truncated")
+	}
+}
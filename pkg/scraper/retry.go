@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryStatus is used when RetryPolicy.RetryStatus is nil.
+var DefaultRetryStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures retries of a single URL. The zero value makes a
+// single attempt. All attempts share the per-URL timeout, and a retry that
+// would have to wait past it is not made.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// The wait before retry n is BaseBackoff * 2^(n-1), capped at
+	// MaxBackoff, unless the response has a Retry-After header.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter is the fraction of the backoff, between 0 and 1, that is
+	// randomly taken off each wait.
+	Jitter float64
+	// RetryStatus lists the status codes worth retrying.
+	RetryStatus []int
+	// RetryError reports whether a request error is worth retrying. By
+	// default every error is, except the context being done and
+	// ErrBodyTooLarge.
+	RetryError func(err error) bool
+}
+
+func (p RetryPolicy) retryable(status int, err error) bool {
+	if err != nil {
+		if p.RetryError != nil {
+			return p.RetryError(err)
+		}
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrBodyTooLarge)
+	}
+	codes := p.RetryStatus
+	if codes == nil {
+		codes = DefaultRetryStatus
+	}
+	return slices.Contains(codes, status)
+}
+
+// backoff returns the wait after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff << (attempt - 1)
+	if d>>(attempt-1) != p.BaseBackoff {
+		d = math.MaxInt64
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(d))
+	}
+	return d
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d unless ctx is done first or d would end past the
+// deadline of ctx.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first n requests with status, or by closing the
+// connection when status is 0.
+func flakyServer(t *testing.T, n int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > n {
+			w.Write([]byte("ok"))
+			return
+		}
+		if status == 0 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Jitter: 0.5}
+	tests := []struct {
+		name     string
+		failures int32
+		status   int
+		attempts int
+		content  string
+	}{
+		{"503", 2, http.StatusServiceUnavailable, 3, "ok"},
+		{"reset", 1, 0, 2, "ok"},
+		{"exhausted", 5, http.StatusBadGateway, 3, ""},
+		{"not retryable", 1, http.StatusNotFound, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := flakyServer(t, tt.failures, tt.status, "")
+			s := NewScraper(Options{Timeout: 5 * time.Second, Retry: policy})
+			result := s.Scrape(context.Background(), []string{srv.URL})[0]
+			if result.Attempts != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, result.Attempts)
+			}
+			if result.Content != tt.content {
+				t.Errorf("expected content %q, got %q (error %v)", tt.content, result.Content, result.Error)
+			}
+		})
+	}
+}
+
+func TestRetryFreshRequest(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "1"})
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Cookie") + "|" + r.Header.Get("X-Attempt")))
+	}))
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	var modified atomic.Int32
+	s := NewScraper(Options{
+		Jar:   jar,
+		Retry: RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+		ModifyRequest: func(req *http.Request) error {
+			req.Header.Set("X-Attempt", strconv.Itoa(int(modified.Add(1))))
+			return nil
+		},
+	})
+	result := s.Scrape(context.Background(), []string{srv.URL})[0]
+	if result.Content != "sid=1|2" {
+		t.Errorf("expected one cookie and a second ModifyRequest call, got %q (error %v)", result.Content, result.Error)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, "1")
+	s := NewScraper(Options{Timeout: 5 * time.Second, Retry: RetryPolicy{MaxAttempts: 2}})
+	start := time.Now()
+	result := s.Scrape(context.Background(), []string{srv.URL})[0]
+	if result.Content != "ok" || calls.Load() != 2 {
+		t.Fatalf("expected a successful retry, got %+v after %d calls", result, calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait for Retry-After, retried after %v", elapsed)
+	}
+
+	// A Retry-After past the timeout is not waited for.
+	srv, calls = flakyServer(t, 1, http.StatusServiceUnavailable, "60")
+	s = NewScraper(Options{Timeout: time.Second, Retry: RetryPolicy{MaxAttempts: 2}})
+	start = time.Now()
+	result = s.Scrape(context.Background(), []string{srv.URL})[0]
+	if calls.Load() != 1 || result.Attempts != 1 {
+		t.Errorf("expected no retry, got %d calls", calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to give up at once, took %v", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 31 Dec 2023 00:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; expected %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w*time.Millisecond {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, w*time.Millisecond, got)
+		}
+	}
+	if got := p.backoff(100); got != p.MaxBackoff {
+		t.Errorf("expected overflow to be capped at %v, got %v", p.MaxBackoff, got)
+	}
+}
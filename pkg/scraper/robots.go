@@ -0,0 +1,280 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDisallowed is the error of a URL that robots.txt does not allow.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+const (
+	// DefaultRobotsTTL is used when Options.RobotsTTL is not set.
+	DefaultRobotsTTL = 24 * time.Hour
+	// DefaultRobotsRetry is used when Options.RobotsRetry is not set.
+	DefaultRobotsRetry = time.Minute
+	// robots.txt files are read up to this size, as RFC 9309 allows.
+	maxRobotsSize = 500 << 10
+	// robotsTimeout bounds fetching robots.txt when Options.Timeout is not set.
+	robotsTimeout = 30 * time.Second
+)
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsRules are the rules of the robots.txt group that applies to the
+// scraper's user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+var (
+	allowAll = &robotsRules{}
+	// disallowAll stands in for a robots.txt that could not be fetched, so
+	// it is cached only for RobotsRetry.
+	disallowAll = &robotsRules{rules: []robotsRule{{allow: false, pattern: "/"}}}
+)
+
+// allowed applies the most specific matching rule to path, which includes
+// the query. On a tie, Allow wins.
+func (r *robotsRules) allowed(path string) bool {
+	allow, best := true, -1
+	for _, rule := range r.rules {
+		if len(rule.pattern) < best || (len(rule.pattern) == best && !rule.allow) {
+			continue
+		}
+		if robotsMatch(rule.pattern, path) {
+			allow, best = rule.allow, len(rule.pattern)
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches path against a robots.txt pattern, where * matches
+// any sequence of characters and a trailing $ anchors the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	path = path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || path == ""
+	}
+	// Matching the middle parts as early as possible leaves the most room
+	// for the last one.
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(path, part)
+		if i < 0 {
+			return false
+		}
+		path = path[i+len(part):]
+	}
+	if anchored {
+		return strings.HasSuffix(path, last)
+	}
+	return strings.Contains(path, last)
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots parses a robots.txt file and returns the rules for
+// userAgent. The groups naming the longest part of userAgent apply, or
+// the * groups if none does; groups for the same agent are merged.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inAgents := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+			continue
+		case "allow", "disallow":
+			// An empty Disallow allows everything, which is the default.
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if secs, err := strconv.ParseFloat(value, 64); current != nil && err == nil && secs > 0 {
+				current.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		}
+		inAgents = false
+	}
+
+	ua := strings.ToLower(userAgent)
+	best := ""
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent != "*" && len(agent) > len(best) && strings.Contains(ua, agent) {
+				best = agent
+			}
+		}
+	}
+	if best == "" {
+		best = "*"
+	}
+	rules := &robotsRules{}
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == best {
+				rules.rules = append(rules.rules, g.rules...)
+				rules.crawlDelay = max(rules.crawlDelay, g.crawlDelay)
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// robotsEntry is a cached robots.txt. ready is closed once rules or err
+// is set.
+type robotsEntry struct {
+	ready   chan struct{}
+	rules   *robotsRules
+	err     error
+	expires time.Time
+}
+
+// robots returns the rules for the host of u, fetching robots.txt once
+// per host and RobotsTTL. It fails if ctx is done first or the robots.txt
+// request could not be built, for example because ModifyRequest failed.
+func (s *Scraper) robots(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	host := strings.ToLower(u.Host)
+	s.robotsMu.Lock()
+	e, ok := s.robotsCache[host]
+	if !ok || (isClosed(e.ready) && time.Now().After(e.expires)) {
+		if s.robotsCache == nil {
+			s.robotsCache = make(map[string]*robotsEntry)
+		}
+		e = &robotsEntry{ready: make(chan struct{})}
+		s.robotsCache[host] = e
+		// The fetch outlives a caller that gives up, as others may wait on it.
+		go s.loadRobots(context.WithoutCancel(ctx), u, host, e)
+	}
+	s.robotsMu.Unlock()
+
+	select {
+	case <-e.ready:
+		return e.rules, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Scraper) loadRobots(ctx context.Context, u *url.URL, host string, e *robotsEntry) {
+	e.rules, e.err = s.fetchRobots(ctx, u)
+	if e.err != nil {
+		// not cached, the next URL of the host tries again
+		s.robotsMu.Lock()
+		if s.robotsCache[host] == e {
+			delete(s.robotsCache, host)
+		}
+		s.robotsMu.Unlock()
+		close(e.ready)
+		return
+	}
+	ttl := s.opts.RobotsTTL
+	if ttl <= 0 {
+		ttl = DefaultRobotsTTL
+	}
+	if e.rules == disallowAll {
+		// the server may be back soon, unlike a robots.txt that says no
+		retry := s.opts.RobotsRetry
+		if retry <= 0 {
+			retry = DefaultRobotsRetry
+		}
+		ttl = min(ttl, retry)
+	}
+	e.expires = time.Now().Add(ttl)
+	if e.rules.crawlDelay > 0 {
+		s.limiter(host).raiseCrawlDelay(e.rules.crawlDelay)
+	}
+	close(e.ready)
+}
+
+// fetchRobots follows RFC 9309: a missing robots.txt allows everything,
+// and one that cannot be fetched because of a server or network error
+// disallows everything. An error building the request is not one of those
+// and is returned instead.
+func (s *Scraper) fetchRobots(ctx context.Context, u *url.URL) (*robotsRules, error) {
+	timeout := s.opts.Timeout
+	if timeout <= 0 {
+		timeout = robotsTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := s.newRequest(ctx, robotsURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("robots.txt request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return disallowAll, nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), s.userAgent()), nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return disallowAll, nil
+	default:
+		return allowAll, nil
+	}
+}
+
+// userAgent is the name matched against robots.txt groups. Without
+// Options.UserAgent, requests go out with Go's default user agent.
+func (s *Scraper) userAgent() string {
+	if s.opts.UserAgent != "" {
+		return s.opts.UserAgent
+	}
+	return "Go-http-client"
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,215 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRobots = `
+# comments and unknown lines are ignored
+Sitemap: https://example.com/sitemap.xml
+
+User-agent: *
+Disallow: /private
+Allow: /private/ok$
+Disallow: /*.pdf$
+Disallow: /search*q=
+
+User-agent: testbot
+User-agent: otherbot
+Disallow: /bots-only
+Crawl-delay: 0.05
+
+User-agent: badbot
+Disallow: /
+`
+
+func TestRobotsRules(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "Mozilla/5.0")
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/private", false},
+		{"/private/secret", false},
+		{"/private/ok", true},
+		{"/private/ok/more", false},
+		{"/doc.pdf", false},
+		{"/doc.pdf?x=1", true},
+		{"/search?lang=en&q=go", false},
+		{"/search?lang=en", true},
+		{"/bots-only", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("allowed(%q) = %v, expected %v", tt.path, got, tt.allowed)
+		}
+	}
+
+	rules = parseRobots(strings.NewReader(testRobots), "TestBot/1.0 (+https://example.com/bot)")
+	if rules.allowed("/bots-only") || !rules.allowed("/private") {
+		t.Error("expected only the testbot group to apply")
+	}
+	if rules.crawlDelay != 50*time.Millisecond {
+		t.Errorf("expected a crawl delay of 50ms, got %v", rules.crawlDelay)
+	}
+	if parseRobots(strings.NewReader(testRobots), "BadBot").allowed("/anything") {
+		t.Error("expected badbot to be disallowed everywhere")
+	}
+}
+
+func TestRobotsMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		match         bool
+	}{
+		{"/a", "/abc", true},
+		{"/a$", "/a", true},
+		{"/a$", "/ab", false},
+		{"/*/c", "/a/b/c", true},
+		{"/*b*d$", "/abcd", true},
+		{"/*b*d$", "/abcde", false},
+		{"*.html", "/x/y.html?z", true},
+		{"/x*$", "/x", true},
+	}
+	for _, tt := range tests {
+		if got := robotsMatch(tt.pattern, tt.path); got != tt.match {
+			t.Errorf("robotsMatch(%q, %q) = %v, expected %v", tt.pattern, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestScraperRobots(t *testing.T) {
+	var robotsFetches atomic.Int32
+	var lastUA atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastUA.Store(r.UserAgent())
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			w.Write([]byte(testRobots))
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer srv.Close()
+
+	s := NewScraper(Options{MaxConcurrent: 4, Robots: true, UserAgent: "testbot/1.0"})
+	urls := []string{srv.URL + "/", srv.URL + "/bots-only", srv.URL + "/private", srv.URL + "/a", srv.URL + "/b"}
+	start := time.Now()
+	results := s.Scrape(context.Background(), urls)
+	elapsed := time.Since(start)
+
+	if !errors.Is(results[1].Error, ErrDisallowed) {
+		t.Errorf("expected ErrDisallowed, got %v", results[1].Error)
+	}
+	for _, i := range []int{0, 2, 3, 4} {
+		if results[i].Error != nil || results[i].Content != "page" {
+			t.Errorf("%s: unexpected result %+v", urls[i], results[i])
+		}
+	}
+	// Four allowed requests 50ms apart.
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("expected Crawl-delay to be applied, took %v", elapsed)
+	}
+	if ua := lastUA.Load(); ua != "testbot/1.0" {
+		t.Errorf("expected the configured user agent, got %v", ua)
+	}
+
+	s.Scrape(context.Background(), urls[:1])
+	if n := robotsFetches.Load(); n != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d", n)
+	}
+}
+
+func TestScraperRobotsStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		allowed bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusForbidden, true},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				w.WriteHeader(tt.status)
+				return
+			}
+			w.Write([]byte("page"))
+		}))
+		s := NewScraper(Options{Robots: true})
+		result := s.Scrape(context.Background(), []string{srv.URL + "/page"})[0]
+		if allowed := !errors.Is(result.Error, ErrDisallowed); allowed != tt.allowed {
+			t.Errorf("robots.txt status %d: expected allowed %v, got error %v", tt.status, tt.allowed, result.Error)
+		}
+		srv.Close()
+	}
+}
+
+func TestScraperRobotsRetry(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if down.Load() {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer srv.Close()
+
+	s := NewScraper(Options{Robots: true, RobotsRetry: 10 * time.Millisecond})
+	if result := s.Scrape(context.Background(), []string{srv.URL + "/page"})[0]; !errors.Is(result.Error, ErrDisallowed) {
+		t.Fatalf("expected an unreachable robots.txt to disallow, got %v", result.Error)
+	}
+
+	down.Store(false)
+	time.Sleep(20 * time.Millisecond)
+	if result := s.Scrape(context.Background(), []string{srv.URL + "/page"})[0]; result.Error != nil {
+		t.Errorf("expected robots.txt to be fetched again and allow, got %v", result.Error)
+	}
+}
+
+func TestScraperRobotsModifyRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer srv.Close()
+
+	errHook := errors.New("hook failed")
+	var fail atomic.Bool
+	fail.Store(true)
+	s := NewScraper(Options{
+		Robots: true,
+		ModifyRequest: func(req *http.Request) error {
+			if req.URL.Path == "/robots.txt" && fail.Load() {
+				return errHook
+			}
+			return nil
+		},
+	})
+	for _, result := range s.Scrape(context.Background(), []string{srv.URL + "/a", srv.URL + "/b"}) {
+		if !errors.Is(result.Error, errHook) {
+			t.Errorf("%s: expected the hook error, got %v", result.URL, result.Error)
+		}
+	}
+
+	// The failure is not cached.
+	fail.Store(false)
+	if result := s.Scrape(context.Background(), []string{srv.URL + "/a"})[0]; result.Error != nil {
+		t.Errorf("unexpected error once the hook succeeds: %v", result.Error)
+	}
+}
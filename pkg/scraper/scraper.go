@@ -2,8 +2,13 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"io"
+	"mime"
 	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -12,68 +17,361 @@ type ScrapResult struct {
 	URL     string
 	Content string
 	Error   error
+	// Attempts is the number of requests made for URL.
+	Attempts int
+
+	// Response metadata of the last attempt, if it got a response.
+	// FinalURL is the URL after redirects and ContentType the media type
+	// without parameters.
+	StatusCode  int
+	FinalURL    string
+	Header      http.Header
+	ContentType string
+	// Bytes is the number of body bytes read and Truncated is set when
+	// the body was cut at Options.MaxBodySize.
+	Bytes     int64
+	Truncated bool
+	// Elapsed is the time spent on URL, including retries.
+	Elapsed time.Duration
+}
+
+// ErrBodyTooLarge is the error of a response larger than
+// Options.MaxBodySize when TruncateBody is not set.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// StatusError is the error of a non-2xx response when
+// Options.ErrorOnNon2xx is set.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected status " + e.Status
+}
+
+// DefaultMaxConcurrent is used when Options.MaxConcurrent is not set.
+const DefaultMaxConcurrent = 10
+
+// Options configures a Scraper.
+type Options struct {
+	// MaxConcurrent bounds the number of requests in flight.
+	MaxConcurrent int
+	// Timeout bounds each URL, including reading the body and retries.
+	// Zero means no timeout beyond the context passed to Scrape or
+	// ScrapeStream.
+	Timeout time.Duration
+	Retry   RetryPolicy
+	// Host limits each host separately, on top of MaxConcurrent.
+	Host HostLimits
+	// UserAgent is sent with every request and matched against the
+	// groups in robots.txt.
+	UserAgent string
+	// Robots makes the scraper fetch robots.txt for every host and skip
+	// disallowed URLs with ErrDisallowed. A Crawl-delay is added to the
+	// host limits. Each robots.txt is cached for RobotsTTL. One that
+	// could not be fetched because of a server or network error disallows
+	// the host only for RobotsRetry, after which it is fetched again.
+	Robots      bool
+	RobotsTTL   time.Duration
+	RobotsRetry time.Duration
+
+	// Client sends the requests; http.DefaultClient by default. A
+	// Transport or Jar set here replaces the one of Client, which is
+	// copied rather than modified.
+	Client    *http.Client
+	Transport http.RoundTripper
+	Jar       http.CookieJar
+	// Header is added to every request, including robots.txt requests.
+	Header http.Header
+	// ModifyRequest is called on every request before it is sent, after
+	// Header and UserAgent are applied. An error fails the URL.
+	ModifyRequest func(req *http.Request) error
+
+	// MaxBodySize bounds the body read per response; zero means no
+	// limit. A larger body fails with ErrBodyTooLarge, or is cut off if
+	// TruncateBody is set.
+	MaxBodySize  int64
+	TruncateBody bool
+	// ErrorOnNon2xx fails responses outside 2xx with a *StatusError,
+	// after any retries. The body and metadata are still returned.
+	ErrorOnNon2xx bool
+}
+
+type Scraper struct {
+	opts   Options
+	client *http.Client
+
+	// limiters keep their state across calls, see hosts.go.
+	limitersMu sync.Mutex
+	limiters   map[string]*hostLimiter
+
+	// robots.txt cache by host, see robots.go.
+	robotsMu    sync.Mutex
+	robotsCache map[string]*robotsEntry
 }
 
+func NewScraper(opts Options) *Scraper {
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = DefaultMaxConcurrent
+	}
+	return &Scraper{opts: opts, client: newClient(opts)}
+}
+
+// ParallelScraper fetches urls with at most maxConcurrent requests in
+// flight. The results are in the order of urls.
 func ParallelScraper(urls []string, maxConcurrent int, timeout time.Duration) []ScrapResult {
-	// define result
-	results := make([]ScrapResult, 1)
-	// define channels
-	resultsChan := make(chan ScrapResult, len(urls))
-	// define semaphore
-	semaphore := make(chan struct{}, maxConcurrent)
-	// define wait group
-	var wg sync.WaitGroup
-
-	// start here
-	for _, url := range urls {
-		wg.Add(1)
-		go func(url string) {
-			defer wg.Done()
-
-			// Rate limiting
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			// create context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), timeout)
-			defer cancel()
-
-			// Create request with context
-			req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-			if err != nil {
-				resultsChan <- ScrapResult{URL: url, Error: err}
-				return
+	s := NewScraper(Options{MaxConcurrent: maxConcurrent, Timeout: timeout})
+	return s.Scrape(context.Background(), urls)
+}
+
+// Scrape fetches urls and returns one result per URL in the order of urls.
+// URLs not fetched before ctx is done get ctx.Err() as their error.
+func (s *Scraper) Scrape(ctx context.Context, urls []string) []ScrapResult {
+	results := make([]ScrapResult, len(urls))
+	fetched := make([]bool, len(urls))
+	s.run(ctx, urls, func(i int, result ScrapResult) bool {
+		results[i] = result
+		fetched[i] = true
+		return true
+	})
+	for i, url := range urls {
+		if !fetched[i] {
+			results[i] = ScrapResult{URL: url, Error: ctx.Err()}
+		}
+	}
+	return results
+}
+
+// ScrapeStream fetches urls and sends each result as soon as it is ready,
+// so results arrive in completion order and are not kept in memory. The
+// channel is closed when every URL is done or ctx is done; the caller must
+// keep receiving until then or cancel ctx.
+func (s *Scraper) ScrapeStream(ctx context.Context, urls []string) <-chan ScrapResult {
+	out := make(chan ScrapResult)
+	go func() {
+		defer close(out)
+		s.run(ctx, urls, func(_ int, result ScrapResult) bool {
+			select {
+			case out <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return out
+}
+
+// run fetches urls with at most MaxConcurrent requests in flight and
+// passes each result with its index to emit, which is never called
+// concurrently. It stops once ctx is done or emit returns false.
+//
+// A single dispatcher starts the requests. It goes round robin over the
+// hosts that have queued URLs, skipping hosts at their concurrency cap or
+// rate limit, so a busy or throttled host never holds up the others.
+func (s *Scraper) run(ctx context.Context, urls []string, emit func(i int, result ScrapResult) bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var hosts []*hostQueue
+	byHost := make(map[string]*hostQueue)
+	for i, url := range urls {
+		host := hostOf(url)
+		hq, ok := byHost[host]
+		if !ok {
+			hq = &hostQueue{host: host}
+			if host != "" {
+				hq.limiter = s.limiter(host)
+				hq.loading = s.opts.Robots
 			}
+			byHost[host] = hq
+			hosts = append(hosts, hq)
+		}
+		hq.queue = append(hq.queue, i)
+	}
+
+	// load robots.txt in the background, a host is not started before
+	// its Crawl-delay is known
+	loaded := make(chan *hostQueue, len(hosts))
+	for _, hq := range hosts {
+		if hq.loading {
+			u, _ := neturl.Parse(urls[hq.queue[0]])
+			go func() {
+				s.robots(ctx, u)
+				loaded <- hq
+			}()
+		}
+	}
 
-			// Perform request
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				resultsChan <- ScrapResult{URL: url, Error: err}
-				return
+	var mu sync.Mutex
+	done := make(chan *hostQueue)
+	queued, inflight, next := len(urls), 0, 0
+	ctxDone := ctx.Done()
+	for queued > 0 || inflight > 0 {
+		var wait time.Duration
+		if ctxDone != nil && queued > 0 && inflight < s.opts.MaxConcurrent {
+			var hq *hostQueue
+			hq, wait = s.pickHost(hosts, next)
+			if hq != nil {
+				i := hq.queue[0]
+				hq.queue = hq.queue[1:]
+				hq.active++
+				queued--
+				inflight++
+				next = slices.Index(hosts, hq) + 1
+				go func() {
+					result := s.fetch(ctx, urls[i])
+					if ctx.Err() == nil {
+						mu.Lock()
+						if !emit(i, result) {
+							cancel()
+						}
+						mu.Unlock()
+					}
+					done <- hq
+				}()
+				continue
 			}
-			defer resp.Body.Close()
+		}
+
+		// wait for a request to finish or a host to become ready
+		var timer *time.Timer
+		var ready <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			ready = timer.C
+		}
+		select {
+		case hq := <-done:
+			hq.active--
+			inflight--
+		case hq := <-loaded:
+			hq.loading = false
+		case <-ready:
+		case <-ctxDone:
+			// stop starting requests, but let the running ones finish
+			ctxDone = nil
+			queued = 0
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+func (s *Scraper) fetch(ctx context.Context, url string) (result ScrapResult) {
+	start := time.Now()
+	defer func() { result.Elapsed = time.Since(start) }()
 
-			// Read body
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				resultsChan <- ScrapResult{URL: url, Error: err}
-				return
+	// create context with timeout
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	// Create request with context
+	req, err := s.newRequest(ctx, url)
+	if err != nil {
+		return ScrapResult{URL: url, Error: err, Attempts: 1}
+	}
+
+	// Check robots.txt
+	if s.opts.Robots {
+		rules, err := s.robots(ctx, req.URL)
+		if err != nil {
+			return ScrapResult{URL: url, Error: err}
+		}
+		if !rules.allowed(req.URL.RequestURI()) {
+			return ScrapResult{URL: url, Error: ErrDisallowed}
+		}
+	}
+
+	retry := s.opts.Retry
+	for attempt := 1; ; attempt++ {
+		// the first attempt was let through by the dispatcher
+		if attempt > 1 {
+			if !s.waitHost(ctx, req.URL) {
+				result.Error = ctx.Err()
+				return result
+			}
+			// A fresh request, so that ModifyRequest runs again and the
+			// client does not add jar cookies on top of the last ones.
+			if req, err = s.newRequest(ctx, url); err != nil {
+				return ScrapResult{URL: url, Error: err, Attempts: attempt}
 			}
+		}
+		result = s.do(req, url)
+		result.Attempts = attempt
+		if attempt >= retry.MaxAttempts || !retry.retryable(result.StatusCode, result.Error) {
+			break
+		}
+		wait, ok := parseRetryAfter(result.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = retry.backoff(attempt)
+		}
+		if !sleep(ctx, wait) {
+			break
+		}
+	}
 
-			resultsChan <- ScrapResult{URL: url, Content: string(body), Error: nil}
-		}(url)
+	if s.opts.ErrorOnNon2xx && result.Error == nil && (result.StatusCode < 200 || result.StatusCode > 299) {
+		result.Error = &StatusError{StatusCode: result.StatusCode, Status: result.Status()}
 	}
+	return result
+}
 
-	// Close result channel when all gorutines complete
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+// do makes a single attempt.
+func (s *Scraper) do(req *http.Request, url string) ScrapResult {
+	// Perform request
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ScrapResult{URL: url, Error: err}
+	}
+	defer resp.Body.Close()
 
-	// Collect result
-	for result := range resultsChan {
-		results = append(results, result)
+	result := ScrapResult{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		FinalURL:   resp.Request.URL.String(),
+		Header:     resp.Header,
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		result.ContentType = mediaType
 	}
 
-	return results
+	// Read body, one byte past the limit to tell whether it was exceeded
+	limit := s.opts.MaxBodySize
+	if limit > 0 && resp.ContentLength > limit && !s.opts.TruncateBody {
+		result.Error = ErrBodyTooLarge
+		return result
+	}
+	var body io.Reader = resp.Body
+	if limit > 0 {
+		body = io.LimitReader(resp.Body, limit+1)
+	}
+	content, err := io.ReadAll(body)
+	result.Bytes = int64(len(content))
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if limit > 0 && int64(len(content)) > limit {
+		if !s.opts.TruncateBody {
+			result.Error = ErrBodyTooLarge
+			return result
+		}
+		content = content[:limit]
+		result.Bytes = limit
+		result.Truncated = true
+	}
+
+	result.Content = string(content)
+	return result
+}
+
+// Status returns the status line text, such as "404 Not Found".
+func (r ScrapResult) Status() string {
+	return strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode)
 }
@@ -1,6 +1,12 @@
 package scraper
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -26,3 +32,87 @@ func TestParallelScraper(t *testing.T) {
 		}
 	}
 }
+
+// newTestServer serves "/slow/N" after N milliseconds and echoes the path.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ms, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/slow/")); err == nil {
+			time.Sleep(time.Duration(ms) * time.Millisecond)
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestParallelScraperOrder(t *testing.T) {
+	srv := newTestServer(t)
+	urls := []string{
+		srv.URL + "/slow/50",
+		srv.URL + "/slow/0",
+		srv.URL + "/slow/20",
+		"://bad",
+		srv.URL + "/slow/5",
+	}
+
+	results := ParallelScraper(urls, 5, 5*time.Second)
+	if len(results) != len(urls) {
+		t.Fatalf("expected %d results, got %d", len(urls), len(results))
+	}
+	for i, result := range results {
+		if result.URL != urls[i] {
+			t.Errorf("result %d: expected %s, got %s", i, urls[i], result.URL)
+		}
+		if i == 3 {
+			if result.Error == nil {
+				t.Error("expected an error for an invalid URL")
+			}
+			continue
+		}
+		if result.Error != nil || result.Content != strings.TrimPrefix(urls[i], srv.URL) {
+			t.Errorf("result %d: unexpected content %q, error %v", i, result.Content, result.Error)
+		}
+	}
+}
+
+func TestScrapeStream(t *testing.T) {
+	srv := newTestServer(t)
+	var urls []string
+	for i := 0; i < 50; i++ {
+		urls = append(urls, fmt.Sprintf("%s/page/%d", srv.URL, i))
+	}
+
+	s := NewScraper(Options{MaxConcurrent: 4, Timeout: 5 * time.Second})
+	seen := make(map[string]bool)
+	for result := range s.ScrapeStream(context.Background(), urls) {
+		if result.Error != nil {
+			t.Errorf("unexpected error for %s: %v", result.URL, result.Error)
+		}
+		seen[result.URL] = true
+	}
+	if len(seen) != len(urls) {
+		t.Errorf("expected %d results, got %d", len(urls), len(seen))
+	}
+}
+
+func TestScrapeStreamCancel(t *testing.T) {
+	srv := newTestServer(t)
+	urls := make([]string, 100)
+	for i := range urls {
+		urls[i] = srv.URL + "/slow/1"
+	}
+
+	s := NewScraper(Options{MaxConcurrent: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := s.ScrapeStream(ctx, urls)
+	<-stream
+	cancel()
+	n := 0
+	for range stream {
+		n++
+	}
+	if n > 2 {
+		t.Errorf("expected at most 2 results after cancel, got %d", n)
+	}
+}
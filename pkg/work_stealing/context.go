@@ -0,0 +1,33 @@
+package workstealing
+
+import (
+	"context"
+	"errors"
+)
+
+// ContextTask is a task that receives the context it was submitted with.
+type ContextTask func(ctx context.Context)
+
+// SubmitContext queues a task that is skipped if ctx is done by the time a
+// worker picks it up; a running task should watch ctx itself. Cancelling
+// ctx costs nothing beyond that check; queued tasks are never searched.
+// If ctx is already done, the task is not queued and ctx.Err() is returned.
+func (pool *WorkStealingPool) SubmitContext(ctx context.Context, task ContextTask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return pool.submit(&job{task: func() { task(ctx) }, ctx: ctx})
+}
+
+// skipDone reports whether a task should be skipped because ctx is done,
+// and reports expired deadlines to OnExpired.
+func (pool *WorkStealingPool) skipDone(ctx context.Context) bool {
+	err := ctx.Err()
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) && pool.opts.OnExpired != nil {
+		pool.opts.OnExpired(ctx)
+	}
+	return true
+}
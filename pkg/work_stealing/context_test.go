@@ -0,0 +1,79 @@
+package workstealing
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitContextSkipsDone(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	defer pool.ShutdownNow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pool.SubmitContext(ctx, func(context.Context) { t.Error("task ran with a done context") }); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// Cancel a task while it waits behind a blocked one.
+	release := make(chan struct{})
+	pool.Submit(func() { <-release })
+	ctx, cancel = context.WithCancel(context.Background())
+	var ran atomic.Bool
+	pool.SubmitContext(ctx, func(context.Context) { ran.Store(true) })
+	cancel()
+	close(release)
+	pool.Wait()
+	if ran.Load() {
+		t.Error("cancelled task ran")
+	}
+}
+
+func TestSubmitContextPassesContext(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	defer pool.ShutdownNow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	pool.SubmitContext(ctx, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+	})
+	<-started
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("running task did not see cancellation")
+	}
+}
+
+func TestSubmitContextOnExpired(t *testing.T) {
+	expired := make(chan context.Context, 1)
+	pool := NewWorkStealingPoolWithOptions(1, PoolOptions{
+		OnExpired: func(ctx context.Context) { expired <- ctx },
+	})
+	defer pool.ShutdownNow()
+
+	release := make(chan struct{})
+	pool.Submit(func() { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	pool.SubmitContext(ctx, func(context.Context) { t.Error("expired task ran") })
+	<-ctx.Done()
+	close(release)
+	pool.Wait()
+
+	select {
+	case got := <-expired:
+		if got != ctx {
+			t.Error("OnExpired got a different context")
+		}
+	default:
+		t.Error("OnExpired was not called")
+	}
+}
@@ -0,0 +1,110 @@
+package workstealing
+
+import "sync/atomic"
+
+/*
+Chase-Lev deque:
+Владелец кладет и забирает задачи снизу без блокировок
+Воры забирают задачи сверху через CAS
+Кольцевой массив растет автоматически
+*/
+
+const initialDequeSize = 32
+
+type dequeArray struct {
+	buf  []atomic.Pointer[job]
+	mask int64
+}
+
+func newDequeArray(size int64) *dequeArray {
+	return &dequeArray{
+		buf:  make([]atomic.Pointer[job], size),
+		mask: size - 1,
+	}
+}
+
+func (a *dequeArray) get(i int64) *job {
+	return a.buf[i&a.mask].Load()
+}
+
+func (a *dequeArray) put(i int64, j *job) {
+	a.buf[i&a.mask].Store(j)
+}
+
+// grow copies the live range [top, bottom) into an array twice the size.
+// Thieves still holding the old array read the same values from it.
+func (a *dequeArray) grow(top, bottom int64) *dequeArray {
+	na := newDequeArray(int64(len(a.buf)) * 2)
+	for i := top; i < bottom; i++ {
+		na.put(i, a.get(i))
+	}
+	return na
+}
+
+// deque is a Chase-Lev dynamic circular work-stealing deque. PushBottom
+// and PopBottom may only be called by the owning worker; Steal may be
+// called by any goroutine.
+type deque struct {
+	top    atomic.Int64
+	bottom atomic.Int64
+	array  atomic.Pointer[dequeArray]
+}
+
+func newDeque() *deque {
+	d := &deque{}
+	d.array.Store(newDequeArray(initialDequeSize))
+	return d
+}
+
+func (d *deque) PushBottom(j *job) {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	a := d.array.Load()
+	if b-t >= int64(len(a.buf)) {
+		a = a.grow(t, b)
+		d.array.Store(a)
+	}
+	a.put(b, j)
+	d.bottom.Store(b + 1)
+}
+
+func (d *deque) PopBottom() *job {
+	b := d.bottom.Load() - 1
+	a := d.array.Load()
+	d.bottom.Store(b)
+	t := d.top.Load()
+	if t > b {
+		// Empty: restore bottom.
+		d.bottom.Store(b + 1)
+		return nil
+	}
+	j := a.get(b)
+	if t == b {
+		// Last element: race thieves for it.
+		if !d.top.CompareAndSwap(t, t+1) {
+			j = nil
+		}
+		d.bottom.Store(b + 1)
+	}
+	return j
+}
+
+// Steal takes the oldest job. It returns nil if the deque is empty or
+// another thief or the owner won the race for the top element.
+func (d *deque) Steal() *job {
+	t := d.top.Load()
+	b := d.bottom.Load()
+	if t >= b {
+		return nil
+	}
+	a := d.array.Load()
+	j := a.get(t)
+	if !d.top.CompareAndSwap(t, t+1) {
+		return nil
+	}
+	return j
+}
+
+func (d *deque) Size() int64 {
+	return max(d.bottom.Load()-d.top.Load(), 0)
+}
@@ -0,0 +1,84 @@
+package workstealing
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDequeOwnerOrder(t *testing.T) {
+	d := newDeque()
+	var got []int
+	for i := 0; i < 100; i++ {
+		d.PushBottom(&job{task: func() { got = append(got, i) }})
+	}
+	if d.Size() != 100 {
+		t.Fatalf("expected size 100, got %d", d.Size())
+	}
+	d.Steal().task()
+	for j := d.PopBottom(); j != nil; j = d.PopBottom() {
+		j.task()
+	}
+	if len(got) != 100 || got[0] != 0 || got[1] != 99 || got[99] != 1 {
+		t.Errorf("expected steal from top and LIFO pops from bottom, got %v", got)
+	}
+}
+
+// TestDequeStress runs the owner against several thieves under the race
+// detector and checks that every task runs exactly once.
+func TestDequeStress(t *testing.T) {
+	const tasks = 20000
+	const thieves = 4
+
+	d := newDeque()
+	runs := make([]atomic.Int32, tasks)
+	var executed atomic.Int64
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if j := d.Steal(); j != nil {
+					j.task()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < tasks; i++ {
+		d.PushBottom(&job{task: func() {
+			runs[i].Add(1)
+			executed.Add(1)
+		}})
+		// Interleave pops so the owner and thieves race for the last element.
+		if i%3 == 0 {
+			if j := d.PopBottom(); j != nil {
+				j.task()
+			}
+		}
+	}
+	for j := d.PopBottom(); j != nil; j = d.PopBottom() {
+		j.task()
+	}
+	for executed.Load() < tasks {
+		if j := d.Steal(); j != nil {
+			j.task()
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	for i := range runs {
+		if n := runs[i].Load(); n != 1 {
+			t.Fatalf("task %d ran %d times", i, n)
+		}
+	}
+}
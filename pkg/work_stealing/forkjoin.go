@@ -0,0 +1,122 @@
+package workstealing
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// ForkJoinTask is a task that can split itself into subtasks.
+type ForkJoinTask func(fj *ForkJoin)
+
+// ForkJoin is passed to a running ForkJoinTask. It must only be used from
+// the goroutine running that task. Without a worker, as for tasks handed
+// back by ShutdownNow, subtasks run inline when forked.
+type ForkJoin struct {
+	pool    *WorkStealingPool
+	worker  *Worker
+	pending atomic.Int64
+}
+
+// Fork pushes task onto the current worker's deque, where it is either
+// run by this worker during Join or stolen by an idle one.
+func (fj *ForkJoin) Fork(task ForkJoinTask) {
+	if fj.worker == nil {
+		task(&ForkJoin{})
+		return
+	}
+	fj.pending.Add(1)
+	fj.worker.deque.PushBottom(&job{fork: func(w *Worker) {
+		defer fj.pending.Add(-1)
+		fj.pool.runForkJoin(w, task)
+	}})
+	fj.pool.wake(nil)
+}
+
+// Submit queues task onto the current worker's deque without joining it,
+// like Submit under LocalFirst but without looking the worker up. It counts
+// as submitted for Wait and Shutdown and returns ErrPoolClosed once the
+// pool is shutting down.
+func (fj *ForkJoin) Submit(task Task) error {
+	if fj.worker == nil {
+		task()
+		return nil
+	}
+	pool := fj.pool
+	pool.pending.Add(1)
+	if pool.closed.Load() {
+		pool.taskDone()
+		return ErrPoolClosed
+	}
+	fj.worker.deque.PushBottom(&job{task: task})
+	pool.wake(nil)
+	return nil
+}
+
+// Join waits for every subtask forked from fj. While waiting, the worker
+// runs other work from its own deque or steals from other workers instead
+// of blocking.
+func (fj *ForkJoin) Join() {
+	w := fj.worker
+	for fj.pending.Load() > 0 {
+		j := fj.pool.getTask(w)
+		if j == nil {
+			j = fj.pool.stealTask(w)
+		}
+		if j != nil {
+			fj.pool.run(w, j)
+			continue
+		}
+		runtime.Gosched()
+	}
+}
+
+// SubmitForkJoin queues a fork-join task. The task counts as finished for
+// Wait and Shutdown once it and all of its subtasks have returned.
+func (pool *WorkStealingPool) SubmitForkJoin(task ForkJoinTask) error {
+	return pool.submitForkJoin(task, nil)
+}
+
+// submitForkJoin calls dropped if ShutdownNow takes the task off a queue
+// before it started.
+func (pool *WorkStealingPool) submitForkJoin(task ForkJoinTask, dropped func()) error {
+	return pool.submit(&job{
+		fork: func(w *Worker) {
+			defer pool.taskDone()
+			pool.runForkJoin(w, task)
+		},
+		// what ShutdownNow hands back: the task run sequentially
+		task:    func() { task(&ForkJoin{}) },
+		dropped: dropped,
+	})
+}
+
+// Invoke runs a fork-join task on the pool and waits for it to finish. It
+// returns ErrPoolClosed if ShutdownNow drops the task before it starts.
+func (pool *WorkStealingPool) Invoke(task ForkJoinTask) error {
+	// Buffered and sent to without blocking, since the task may still be
+	// run by whoever ShutdownNow returned it to.
+	result := make(chan error, 1)
+	send := func(err error) {
+		select {
+		case result <- err:
+		default:
+		}
+	}
+	err := pool.submitForkJoin(func(fj *ForkJoin) {
+		defer send(nil)
+		task(fj)
+		fj.Join()
+	}, func() { send(ErrPoolClosed) })
+	if err != nil {
+		return err
+	}
+	return <-result
+}
+
+// runForkJoin runs task on w and joins any subtasks it left unjoined,
+// even if the task panicked, since they may use its data.
+func (pool *WorkStealingPool) runForkJoin(w *Worker, task ForkJoinTask) {
+	fj := &ForkJoin{pool: pool, worker: w}
+	pool.safeRun(func() { task(fj) })
+	fj.Join()
+}
@@ -0,0 +1,203 @@
+package workstealing
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+const (
+	fibThreshold  = 15
+	sortThreshold = 1024
+)
+
+func seqFib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return seqFib(n-1) + seqFib(n-2)
+}
+
+func parallelFib(fj *ForkJoin, n int, out *int) {
+	if n < fibThreshold {
+		*out = seqFib(n)
+		return
+	}
+	var a, b int
+	fj.Fork(func(fj *ForkJoin) { parallelFib(fj, n-1, &a) })
+	parallelFib(fj, n-2, &b)
+	fj.Join()
+	*out = a + b
+}
+
+func partition(s []int) int {
+	pivot := s[len(s)/2]
+	s[len(s)/2], s[len(s)-1] = s[len(s)-1], s[len(s)/2]
+	i := 0
+	for j := 0; j < len(s)-1; j++ {
+		if s[j] < pivot {
+			s[i], s[j] = s[j], s[i]
+			i++
+		}
+	}
+	s[i], s[len(s)-1] = s[len(s)-1], s[i]
+	return i
+}
+
+func parallelQuicksort(fj *ForkJoin, s []int) {
+	if len(s) < sortThreshold {
+		slices.Sort(s)
+		return
+	}
+	p := partition(s)
+	fj.Fork(func(fj *ForkJoin) { parallelQuicksort(fj, s[:p]) })
+	parallelQuicksort(fj, s[p+1:])
+	fj.Join()
+}
+
+func TestForkJoinFib(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+
+	var got int
+	if err := pool.Invoke(func(fj *ForkJoin) { parallelFib(fj, 25, &got) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := seqFib(25); got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+}
+
+func TestForkJoinQuicksort(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+
+	data := rand.Perm(100000)
+	pool.Invoke(func(fj *ForkJoin) { parallelQuicksort(fj, data) })
+	if !slices.IsSorted(data) {
+		t.Errorf("expected sorted slice")
+	}
+}
+
+func TestForkJoinWait(t *testing.T) {
+	pool := NewWorkStealingPool(2)
+	defer pool.ShutdownNow()
+
+	var got int
+	pool.SubmitForkJoin(func(fj *ForkJoin) {
+		// Subtasks left unjoined are joined when the task returns.
+		fj.Fork(func(*ForkJoin) { got = seqFib(20) })
+	})
+	pool.Wait()
+	if got != seqFib(20) {
+		t.Errorf("expected subtask to finish before Wait returns")
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			seqFib(30)
+		}
+	})
+	b.Run("forkjoin", func(b *testing.B) {
+		pool := NewWorkStealingPool(runtime.GOMAXPROCS(0))
+		defer pool.ShutdownNow()
+		for i := 0; i < b.N; i++ {
+			var out int
+			pool.Invoke(func(fj *ForkJoin) { parallelFib(fj, 30, &out) })
+		}
+	})
+}
+
+func BenchmarkQuicksort(b *testing.B) {
+	data := rand.Perm(1 << 20)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			s := slices.Clone(data)
+			b.StartTimer()
+			slices.Sort(s)
+		}
+	})
+	b.Run("forkjoin", func(b *testing.B) {
+		pool := NewWorkStealingPool(runtime.GOMAXPROCS(0))
+		defer pool.ShutdownNow()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			s := slices.Clone(data)
+			b.StartTimer()
+			pool.Invoke(func(fj *ForkJoin) { parallelQuicksort(fj, s) })
+		}
+	})
+}
+
+func TestShutdownNowReturnsForkJoinRoots(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	var fib int
+	pool.SubmitForkJoin(func(fj *ForkJoin) { parallelFib(fj, 20, &fib) })
+	invoked := make(chan error, 1)
+	go func() {
+		invoked <- pool.Invoke(func(fj *ForkJoin) {})
+	}()
+	for pool.snapshot()[0].depth() < 2 {
+		runtime.Gosched()
+	}
+
+	result := make(chan []Task)
+	go func() { result <- pool.ShutdownNow() }()
+	for !pool.stopped.Load() {
+		runtime.Gosched()
+	}
+	close(release)
+
+	tasks := <-result
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 unstarted tasks, got %d", len(tasks))
+	}
+	if err := <-invoked; err != ErrPoolClosed {
+		t.Errorf("expected Invoke to return ErrPoolClosed, got %v", err)
+	}
+	// The returned roots run sequentially.
+	for _, task := range tasks {
+		if task == nil {
+			t.Fatal("ShutdownNow returned a nil task")
+		}
+		task()
+	}
+	if fib != seqFib(20) {
+		t.Errorf("expected fib(20) = %d, got %d", seqFib(20), fib)
+	}
+}
+
+func TestForkJoinSubmit(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	defer pool.ShutdownNow()
+
+	ran := make(chan bool, 1)
+	var queued int64
+	err := pool.Invoke(func(fj *ForkJoin) {
+		fj.Submit(func() { ran <- true })
+		// one worker, so nothing can have taken it yet
+		queued = fj.worker.deque.Size()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool.Wait()
+	if queued != 1 {
+		t.Errorf("expected the task on the worker's own deque, found %d", queued)
+	}
+	if !<-ran {
+		t.Errorf("expected the submitted task to run")
+	}
+}
@@ -0,0 +1,243 @@
+package workstealing
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+)
+
+// Future is the result of a function submitted with Submit.
+type Future[T any] struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	completed bool
+	value     T
+	err       error
+	callbacks []func()
+}
+
+func newFuture[T any](cancel context.CancelFunc) *Future[T] {
+	return &Future[T]{done: make(chan struct{}), cancel: cancel}
+}
+
+// Submit runs fn on the pool and returns a Future for its result. The
+// context passed to fn is cancelled by Future.Cancel. If the pool is
+// closed, or ShutdownNow drops fn before it starts, the future completes
+// with ErrPoolClosed.
+func Submit[T any](pool *WorkStealingPool, fn func(ctx context.Context) (T, error)) *Future[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := newFuture[T](cancel)
+	err := pool.submit(&job{
+		task: func() {
+			defer cancel()
+			defer recoverInto(pool, f)
+			if ctx.Err() != nil {
+				var zero T
+				f.complete(zero, ctx.Err())
+				return
+			}
+			f.complete(fn(ctx))
+		},
+		dropped: func() {
+			// a handed back task finds ctx cancelled and leaves f alone
+			cancel()
+			var zero T
+			f.complete(zero, ErrPoolClosed)
+		},
+	})
+	if err != nil {
+		cancel()
+		var zero T
+		f.complete(zero, err)
+	}
+	return f
+}
+
+// Get waits for the result or for ctx to be done.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done is closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel completes the future with context.Canceled unless it already has
+// a result, and cancels the context of the running function. A function
+// that has not started yet is skipped.
+func (f *Future[T]) Cancel() {
+	f.cancel()
+	var zero T
+	f.complete(zero, context.Canceled)
+}
+
+// complete stores the first result and runs the registered callbacks.
+func (f *Future[T]) complete(value T, err error) bool {
+	f.mu.Lock()
+	if f.completed {
+		f.mu.Unlock()
+		return false
+	}
+	f.completed = true
+	f.value, f.err = value, err
+	callbacks := f.callbacks
+	f.callbacks = nil
+	close(f.done)
+	f.mu.Unlock()
+
+	for _, cb := range callbacks {
+		cb()
+	}
+	return true
+}
+
+// recoverInto completes f with a *PanicError if the function running it
+// panicked, and reports the panic like any other failed task.
+func recoverInto[T any](pool *WorkStealingPool, f *Future[T]) {
+	if r := recover(); r != nil {
+		stack := debug.Stack()
+		pool.reportPanic(r, stack)
+		var zero T
+		f.complete(zero, &PanicError{Value: r, Stack: stack})
+	}
+}
+
+// onComplete runs cb after the future completes, immediately if it
+// already has.
+func (f *Future[T]) onComplete(cb func()) {
+	f.mu.Lock()
+	if !f.completed {
+		f.callbacks = append(f.callbacks, cb)
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+	cb()
+}
+
+// All completes with every value in order once all futures succeed, or
+// with the first error, in which case the remaining futures are cancelled.
+// Cancelling the result cancels all inputs.
+func All[T any](futures ...*Future[T]) *Future[[]T] {
+	result := newFuture[[]T](func() {
+		for _, f := range futures {
+			f.Cancel()
+		}
+	})
+	if len(futures) == 0 {
+		result.complete([]T{}, nil)
+		return result
+	}
+
+	var mu sync.Mutex
+	remaining := len(futures)
+	for _, f := range futures {
+		f.onComplete(func() {
+			if f.err != nil {
+				if result.complete(nil, f.err) {
+					result.cancel()
+				}
+				return
+			}
+			mu.Lock()
+			remaining--
+			last := remaining == 0
+			mu.Unlock()
+			if last {
+				values := make([]T, len(futures))
+				for i, f := range futures {
+					values[i] = f.value
+				}
+				result.complete(values, nil)
+			}
+		})
+	}
+	return result
+}
+
+// Any completes with the first successful value and cancels the other
+// futures. If all of them fail it completes with their joined errors.
+func Any[T any](futures ...*Future[T]) *Future[T] {
+	result := newFuture[T](func() {
+		for _, f := range futures {
+			f.Cancel()
+		}
+	})
+	if len(futures) == 0 {
+		var zero T
+		result.complete(zero, errors.New("no futures"))
+		return result
+	}
+
+	var mu sync.Mutex
+	errs := make([]error, 0, len(futures))
+	for _, f := range futures {
+		f.onComplete(func() {
+			if f.err == nil {
+				if result.complete(f.value, nil) {
+					result.cancel()
+				}
+				return
+			}
+			mu.Lock()
+			errs = append(errs, f.err)
+			failed := len(errs) == len(futures)
+			mu.Unlock()
+			if failed {
+				var zero T
+				result.complete(zero, errors.Join(errs...))
+			}
+		})
+	}
+	return result
+}
+
+// Then submits fn to the pool with the value of f once f succeeds. An
+// error from f is passed through without running fn. Cancelling the
+// result also cancels f. As with Submit, the result completes with
+// ErrPoolClosed if the pool is closed or ShutdownNow drops fn.
+func Then[T, U any](pool *WorkStealingPool, f *Future[T], fn func(ctx context.Context, value T) (U, error)) *Future[U] {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := newFuture[U](func() {
+		cancel()
+		f.Cancel()
+	})
+	f.onComplete(func() {
+		var zero U
+		if f.err != nil {
+			cancel()
+			result.complete(zero, f.err)
+			return
+		}
+		err := pool.submit(&job{
+			task: func() {
+				defer cancel()
+				defer recoverInto(pool, result)
+				if ctx.Err() != nil {
+					result.complete(zero, ctx.Err())
+					return
+				}
+				result.complete(fn(ctx, f.value))
+			},
+			dropped: func() {
+				cancel()
+				result.complete(zero, ErrPoolClosed)
+			},
+		})
+		if err != nil {
+			cancel()
+			result.complete(zero, err)
+		}
+	})
+	return result
+}
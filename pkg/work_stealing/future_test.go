@@ -0,0 +1,163 @@
+package workstealing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFutureGet(t *testing.T) {
+	pool := NewWorkStealingPool(2)
+	defer pool.ShutdownNow()
+
+	f := Submit(pool, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	v, err := f.Get(context.Background())
+	if err != nil || v != 42 {
+		t.Errorf("expected 42, got %v, %v", v, err)
+	}
+	select {
+	case <-f.Done():
+	default:
+		t.Errorf("expected Done to be closed")
+	}
+}
+
+func TestFutureCancel(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	defer pool.ShutdownNow()
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	f := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return 0, ctx.Err()
+	})
+	<-started
+	f.Cancel()
+	if _, err := f.Get(context.Background()); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	<-stopped
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	slow := Submit(pool, func(context.Context) (int, error) {
+		time.Sleep(50 * time.Millisecond)
+		return 1, nil
+	})
+	if _, err := slow.Get(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded from Get, got %v", err)
+	}
+}
+
+func TestFutureCombinators(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+
+	var futures []*Future[int]
+	for i := 1; i <= 3; i++ {
+		futures = append(futures, Submit(pool, func(context.Context) (int, error) {
+			return i, nil
+		}))
+	}
+	sum := Then(pool, All(futures...), func(_ context.Context, values []int) (string, error) {
+		total := 0
+		for _, v := range values {
+			total += v
+		}
+		return strconv.Itoa(total), nil
+	})
+	if v, err := sum.Get(context.Background()); err != nil || v != "6" {
+		t.Errorf("expected \"6\", got %q, %v", v, err)
+	}
+
+	errBoom := errors.New("boom")
+	failing := Submit(pool, func(context.Context) (int, error) { return 0, errBoom })
+	blocked := Submit(pool, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if _, err := All(failing, blocked).Get(context.Background()); err != errBoom {
+		t.Errorf("expected All to fail with boom, got %v", err)
+	}
+	if _, err := blocked.Get(context.Background()); err != context.Canceled {
+		t.Errorf("expected remaining future to be cancelled, got %v", err)
+	}
+
+	failing = Submit(pool, func(context.Context) (int, error) { return 0, errBoom })
+	ok := Submit(pool, func(context.Context) (int, error) { return 7, nil })
+	if v, err := Any(failing, ok).Get(context.Background()); err != nil || v != 7 {
+		t.Errorf("expected Any to return 7, got %v, %v", v, err)
+	}
+	// Any cancels the losers, so use a fresh future from here on.
+	failing = Submit(pool, func(context.Context) (int, error) { return 0, errBoom })
+	if _, err := Any(failing).Get(context.Background()); !errors.Is(err, errBoom) {
+		t.Errorf("expected Any to fail with boom, got %v", err)
+	}
+
+	skipped := Then(pool, failing, func(context.Context, int) (int, error) {
+		t.Errorf("continuation ran after a failed future")
+		return 0, nil
+	})
+	if _, err := skipped.Get(context.Background()); err != errBoom {
+		t.Errorf("expected Then to pass the error through, got %v", err)
+	}
+}
+
+func TestFutureShutdownNow(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	first := Submit(pool, func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	queued := Submit(pool, func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	// Already complete, so Then queues its function right away.
+	base := newFuture[int](func() {})
+	base.complete(1, nil)
+	chained := Then(pool, base, func(ctx context.Context, v int) (int, error) {
+		return v + 1, nil
+	})
+
+	result := make(chan []Task)
+	go func() { result <- pool.ShutdownNow() }()
+	for !pool.stopped.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	tasks := <-result
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := queued.Get(ctx); err != ErrPoolClosed {
+		t.Errorf("expected the dropped future to fail with ErrPoolClosed, got %v", err)
+	}
+	if _, err := chained.Get(ctx); err != ErrPoolClosed {
+		t.Errorf("expected the dropped Then to fail with ErrPoolClosed, got %v", err)
+	}
+	if v, err := first.Get(ctx); err != nil || v != 1 {
+		t.Errorf("expected the running future to finish, got %v, %v", v, err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("expected 2 unstarted tasks, got %d", len(tasks))
+	}
+	// Running a handed back task does not overwrite the result.
+	for _, task := range tasks {
+		task()
+	}
+	if _, err := queued.Get(ctx); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed to stick, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package workstealing
+
+// keyedQueue holds the tasks of one key waiting behind its head task.
+// Only the head is in a worker queue; the next task is queued when it
+// finishes, so tasks of a key never overlap and other keys never wait
+// behind them.
+type keyedQueue struct {
+	key   string
+	tasks queue
+}
+
+// SubmitKeyed queues a task that runs after every task previously
+// submitted with the same key has finished. Tasks with different keys run
+// in parallel and are balanced across workers like any other task.
+func (pool *WorkStealingPool) SubmitKeyed(key string, task Task) error {
+	// Held until the task is queued behind its key or on a worker, as in
+	// submit, so that ShutdownNow sees it either way.
+	pool.submitMu.RLock()
+	defer pool.submitMu.RUnlock()
+	if pool.closed.Load() {
+		return ErrPoolClosed
+	}
+	pool.pending.Add(1)
+
+	j := &job{task: task}
+	pool.keyedMu.Lock()
+	if q, ok := pool.keyed[key]; ok {
+		q.tasks.PushBack(j)
+		pool.keyedMu.Unlock()
+		return nil
+	}
+	if pool.keyed == nil {
+		pool.keyed = make(map[string]*keyedQueue)
+	}
+	j.keyed = &keyedQueue{key: key}
+	pool.keyed[key] = j.keyed
+	pool.keyedMu.Unlock()
+
+	pool.enqueue(j)
+	return nil
+}
+
+// keyedDone queues the next task of q, or forgets the key when none is left.
+func (pool *WorkStealingPool) keyedDone(q *keyedQueue) {
+	pool.keyedMu.Lock()
+	next := q.tasks.PopFront()
+	if next == nil {
+		delete(pool.keyed, q.key)
+	}
+	pool.keyedMu.Unlock()
+
+	if next != nil {
+		next.keyed = q
+		pool.enqueue(next)
+	}
+}
+
+// takeKeyed removes the tasks waiting behind a head task, for ShutdownNow.
+func (pool *WorkStealingPool) takeKeyed() []*job {
+	pool.keyedMu.Lock()
+	defer pool.keyedMu.Unlock()
+	var jobs []*job
+	for key, q := range pool.keyed {
+		jobs = append(jobs, q.tasks.TakeAll()...)
+		delete(pool.keyed, key)
+	}
+	return jobs
+}
@@ -0,0 +1,104 @@
+package workstealing
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitKeyedOrder(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+
+	const keys, perKey = 8, 200
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	running := make([]atomic.Int32, keys)
+	for i := 0; i < perKey; i++ {
+		for k := 0; k < keys; k++ {
+			key := fmt.Sprintf("key-%d", k)
+			pool.SubmitKeyed(key, func() {
+				if running[k].Add(1) != 1 {
+					t.Errorf("tasks of %s overlap", key)
+				}
+				mu.Lock()
+				seen[key] = append(seen[key], i)
+				mu.Unlock()
+				running[k].Add(-1)
+			})
+		}
+	}
+	pool.Wait()
+
+	for k := 0; k < keys; k++ {
+		key := fmt.Sprintf("key-%d", k)
+		got := seen[key]
+		if len(got) != perKey {
+			t.Fatalf("expected %d tasks for %s, got %d", perKey, key, len(got))
+		}
+		for i, v := range got {
+			if v != i {
+				t.Fatalf("%s ran out of order: task %d at position %d", key, v, i)
+			}
+		}
+	}
+	if n := len(pool.keyed); n != 0 {
+		t.Errorf("expected no keys left, got %d", n)
+	}
+}
+
+func TestSubmitKeyedNoHeadOfLineBlocking(t *testing.T) {
+	pool := NewWorkStealingPool(2)
+	defer pool.ShutdownNow()
+
+	release := make(chan struct{})
+	pool.SubmitKeyed("slow", func() { <-release })
+	pool.SubmitKeyed("slow", func() {})
+
+	done := make(chan struct{})
+	var n atomic.Int32
+	for i := 0; i < 10; i++ {
+		pool.SubmitKeyed("fast", func() {
+			if n.Add(1) == 10 {
+				close(done)
+			}
+		})
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tasks of another key were blocked")
+	}
+	close(release)
+	pool.Wait()
+}
+
+func TestShutdownNowReturnsKeyedTasks(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool.SubmitKeyed("a", func() {
+		close(started)
+		<-release
+	})
+	for i := 0; i < 3; i++ {
+		pool.SubmitKeyed("a", func() {})
+	}
+	<-started
+
+	result := make(chan []Task)
+	go func() { result <- pool.ShutdownNow() }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	// The head task's successor may already have been queued on a worker.
+	if tasks := <-result; len(tasks) != 3 {
+		t.Errorf("expected 3 unstarted tasks, got %d", len(tasks))
+	}
+	if n := pool.pending.Load(); n != 0 {
+		t.Errorf("expected no pending tasks, got %d", n)
+	}
+}
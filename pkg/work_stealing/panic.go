@@ -0,0 +1,38 @@
+package workstealing
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error a Future completes with when its function panics.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// FailedTasks returns the number of tasks that have panicked.
+func (pool *WorkStealingPool) FailedTasks() uint64 {
+	return pool.failed.Load()
+}
+
+// safeRun calls fn and reports a panic instead of letting it kill the worker.
+func (pool *WorkStealingPool) safeRun(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			pool.reportPanic(r, debug.Stack())
+		}
+	}()
+	fn()
+}
+
+func (pool *WorkStealingPool) reportPanic(value interface{}, stack []byte) {
+	pool.failed.Add(1)
+	if pool.opts.OnPanic != nil {
+		pool.opts.OnPanic(value, stack)
+	}
+}
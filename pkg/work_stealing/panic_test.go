@@ -0,0 +1,76 @@
+package workstealing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPanicIsolation(t *testing.T) {
+	var mu sync.Mutex
+	var values []interface{}
+	var stack []byte
+	pool := NewWorkStealingPoolWithOptions(2, PoolOptions{
+		OnPanic: func(value interface{}, s []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			values = append(values, value)
+			stack = s
+		},
+	})
+	defer pool.ShutdownNow()
+
+	var ran atomic.Int64
+	for i := 0; i < 10; i++ {
+		pool.Submit(func() {
+			if i%2 == 0 {
+				panic("boom")
+			}
+			ran.Add(1)
+		})
+	}
+	pool.Wait()
+
+	if ran.Load() != 5 {
+		t.Errorf("expected 5 tasks to run after panics, got %d", ran.Load())
+	}
+	if pool.FailedTasks() != 5 {
+		t.Errorf("expected 5 failed tasks, got %d", pool.FailedTasks())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(values) != 5 || values[0] != "boom" {
+		t.Errorf("expected OnPanic to receive 5 panics, got %v", values)
+	}
+	if !strings.Contains(string(stack), "TestPanicIsolation") {
+		t.Errorf("expected stack to include the panicking task")
+	}
+}
+
+func TestPanicInFutureAndForkJoin(t *testing.T) {
+	pool := NewWorkStealingPool(2)
+	defer pool.ShutdownNow()
+
+	f := Submit(pool, func(context.Context) (int, error) {
+		panic("future")
+	})
+	var perr *PanicError
+	if _, err := f.Get(context.Background()); !errors.As(err, &perr) || perr.Value != "future" {
+		t.Errorf("expected *PanicError, got %v", err)
+	}
+
+	var done atomic.Bool
+	err := pool.Invoke(func(fj *ForkJoin) {
+		fj.Fork(func(*ForkJoin) { panic("subtask") })
+		fj.Fork(func(*ForkJoin) { done.Store(true) })
+	})
+	if err != nil || !done.Load() {
+		t.Errorf("expected fork-join task to complete despite a panicking subtask")
+	}
+	if pool.FailedTasks() != 2 {
+		t.Errorf("expected 2 failed tasks, got %d", pool.FailedTasks())
+	}
+}
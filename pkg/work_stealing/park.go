@@ -0,0 +1,113 @@
+package workstealing
+
+// idleSpins is how many empty rounds a worker makes, yielding between
+// them, before it parks.
+const idleSpins = 64
+
+// park blocks w until a waker notifies it. The worker registers as idle
+// before re-checking for work, and enqueue publishes work before looking
+// for idle workers, so a wakeup cannot be lost between the two.
+func (pool *WorkStealingPool) park(w *Worker) {
+	pool.idleMu.Lock()
+	pool.idleWorkers = append(pool.idleWorkers, w)
+	pool.idleCount.Add(1)
+	pool.idleMu.Unlock()
+
+	if pool.hasWork() || pool.shouldExit() || w.retiring.Load() {
+		if pool.removeIdle(w) {
+			return
+		}
+		// A waker already took w off the list; consume its notification.
+	}
+
+	w.parked.Store(true)
+	w.stats.parks.Add(1)
+	w.mu.Lock()
+	for !w.notified {
+		w.cond.Wait()
+	}
+	w.notified = false
+	w.mu.Unlock()
+	w.parked.Store(false)
+	w.stats.unparks.Add(1)
+}
+
+// wake unparks target if it is idle, otherwise any idle worker so that it
+// can steal. A nil target wakes any idle worker.
+func (pool *WorkStealingPool) wake(target *Worker) {
+	if pool.idleCount.Load() == 0 {
+		return
+	}
+	pool.idleMu.Lock()
+	n := len(pool.idleWorkers)
+	if n == 0 {
+		pool.idleMu.Unlock()
+		return
+	}
+	i := n - 1
+	for j, w := range pool.idleWorkers {
+		if w == target {
+			i = j
+			break
+		}
+	}
+	w := pool.idleWorkers[i]
+	pool.idleWorkers = append(pool.idleWorkers[:i], pool.idleWorkers[i+1:]...)
+	pool.idleCount.Add(-1)
+	pool.idleMu.Unlock()
+
+	w.notify()
+}
+
+// wakeWorker unparks w if it is idle.
+func (pool *WorkStealingPool) wakeWorker(w *Worker) {
+	if pool.removeIdle(w) {
+		w.notify()
+	}
+}
+
+// wakeAll unparks every idle worker, used when the pool shuts down.
+func (pool *WorkStealingPool) wakeAll() {
+	pool.idleMu.Lock()
+	idle := pool.idleWorkers
+	pool.idleWorkers = nil
+	pool.idleCount.Store(0)
+	pool.idleMu.Unlock()
+
+	for _, w := range idle {
+		w.notify()
+	}
+}
+
+func (pool *WorkStealingPool) removeIdle(w *Worker) bool {
+	pool.idleMu.Lock()
+	defer pool.idleMu.Unlock()
+	for i, idle := range pool.idleWorkers {
+		if idle == w {
+			pool.idleWorkers = append(pool.idleWorkers[:i], pool.idleWorkers[i+1:]...)
+			pool.idleCount.Add(-1)
+			return true
+		}
+	}
+	return false
+}
+
+func (pool *WorkStealingPool) hasWork() bool {
+	for _, w := range pool.snapshot() {
+		if w.depth() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (pool *WorkStealingPool) shouldExit() bool {
+	return pool.stopped.Load() || (pool.closed.Load() && pool.pending.Load() == 0)
+}
+
+func (w *Worker) notify() {
+	w.mu.Lock()
+	w.notified = true
+	w.cond.Signal()
+	w.mu.Unlock()
+}
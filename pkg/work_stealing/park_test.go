@@ -0,0 +1,79 @@
+package workstealing
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"testing"
+	"time"
+)
+
+func userCPUSeconds() float64 {
+	sample := []metrics.Sample{{Name: "/cpu/classes/user:cpu-seconds"}}
+	metrics.Read(sample)
+	return sample[0].Value.Float64()
+}
+
+func TestIdleWorkersPark(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+	pool.Submit(func() {})
+	pool.Wait()
+
+	deadline := time.Now().Add(time.Second)
+	for pool.idleCount.Load() != 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := pool.idleCount.Load(); n != 4 {
+		t.Fatalf("expected 4 parked workers, got %d", n)
+	}
+
+	// A submission wakes a parked worker.
+	done := make(chan struct{})
+	pool.Submit(func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("task was not picked up by a parked worker")
+	}
+}
+
+// BenchmarkIdleCPU reports the CPU used by an idle pool as a fraction of
+// one core; it should be close to zero.
+func BenchmarkIdleCPU(b *testing.B) {
+	pool := NewWorkStealingPool(runtime.GOMAXPROCS(0))
+	defer pool.ShutdownNow()
+	pool.Submit(func() {})
+	pool.Wait()
+	time.Sleep(10 * time.Millisecond)
+
+	start := userCPUSeconds()
+	wall := time.Now()
+	for i := 0; i < b.N; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	b.ReportMetric((userCPUSeconds()-start)/time.Since(wall).Seconds(), "cpu/core")
+}
+
+// BenchmarkBurstFromIdle measures submitting a burst of tasks to a pool
+// whose workers are parked and waiting for all of them.
+func BenchmarkBurstFromIdle(b *testing.B) {
+	const burst = 64
+	size := runtime.GOMAXPROCS(0)
+	pool := NewWorkStealingPool(size)
+	defer pool.ShutdownNow()
+
+	var wg sync.WaitGroup
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for int(pool.idleCount.Load()) < size {
+			runtime.Gosched()
+		}
+		b.StartTimer()
+		wg.Add(burst)
+		for j := 0; j < burst; j++ {
+			pool.Submit(wg.Done)
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,196 @@
+package workstealing
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AutoscaleOptions configures the autoscaler. Zero thresholds disable the
+// corresponding rule.
+type AutoscaleOptions struct {
+	MinWorkers int
+	MaxWorkers int
+	// Interval is how often the autoscaler looks at the pool; 100ms by default.
+	Interval time.Duration
+	// A worker is added when the average number of queued tasks per worker
+	// exceeds QueueDepth, or the average time tasks wait in a queue
+	// exceeds MaxWait.
+	QueueDepth int
+	MaxWait    time.Duration
+	// A worker is retired after being parked for IdleTimeout.
+	IdleTimeout time.Duration
+}
+
+// Size returns the current number of workers.
+func (pool *WorkStealingPool) Size() int {
+	return len(pool.snapshot())
+}
+
+// Resize grows or shrinks the pool to n workers (at least one). Idle
+// workers are retired first. A retiring worker finishes its current task
+// and moves its queued tasks to the remaining workers before exiting.
+func (pool *WorkStealingPool) Resize(n int) error {
+	pool.resizeMu.Lock()
+	defer pool.resizeMu.Unlock()
+	if pool.closed.Load() {
+		return ErrPoolClosed
+	}
+	pool.resizeLocked(max(n, 1))
+	return nil
+}
+
+// resize is used by the constructor, before anything else can run.
+func (pool *WorkStealingPool) resize(n int) {
+	pool.resizeMu.Lock()
+	defer pool.resizeMu.Unlock()
+	pool.resizeLocked(n)
+}
+
+func (pool *WorkStealingPool) resizeLocked(n int) {
+	current := pool.snapshot()
+	if n == len(current) {
+		return
+	}
+
+	var workers, added, retired []*Worker
+	if n > len(current) {
+		workers = slices.Clone(current)
+		for len(workers) < n {
+			w := &Worker{
+				id:    pool.nextID,
+				deque: newDeque(),
+				inbox: &queue{},
+			}
+			w.cond = sync.NewCond(&w.mu)
+			w.lastActive.Store(time.Now().UnixNano())
+			pool.nextID++
+			workers = append(workers, w)
+			added = append(added, w)
+		}
+	} else {
+		retired = pickRetired(current, len(current)-n)
+		for _, w := range current {
+			if !slices.Contains(retired, w) {
+				workers = append(workers, w)
+			}
+		}
+	}
+
+	// Publish the new topology before starting or stopping anyone, so new
+	// workers are visible to thieves and retired ones no longer receive
+	// submissions.
+	pool.workers.Store(&workers)
+	for _, w := range workers {
+		stealers := make([]*Worker, 0, len(workers)-1)
+		for _, other := range workers {
+			if other != w {
+				stealers = append(stealers, other)
+			}
+		}
+		w.stealers.Store(&stealers)
+	}
+
+	for _, w := range added {
+		pool.workersWG.Add(1)
+		go pool.runWorker(w)
+	}
+	for _, w := range retired {
+		w.retiring.Store(true)
+		pool.wakeWorker(w)
+	}
+}
+
+// pickRetired chooses k workers to retire: parked ones first, longest idle
+// first, then the most recently added.
+func pickRetired(workers []*Worker, k int) []*Worker {
+	candidates := slices.Clone(workers)
+	slices.SortStableFunc(candidates, func(a, b *Worker) int {
+		if a.parked.Load() != b.parked.Load() {
+			if a.parked.Load() {
+				return -1
+			}
+			return 1
+		}
+		if a.parked.Load() {
+			return cmp.Compare(a.lastActive.Load(), b.lastActive.Load())
+		}
+		return cmp.Compare(b.id, a.id)
+	})
+	return candidates[:k]
+}
+
+// retire runs on the retiring worker's own goroutine, so it may pop its
+// deque as the owner. Thieves can keep stealing from it meanwhile.
+func (pool *WorkStealingPool) retire(w *Worker) {
+	w.mu.Lock()
+	w.retired = true
+	jobs := w.inbox.TakeAll()
+	w.inboxLen.Store(0)
+	w.mu.Unlock()
+
+	for j := w.deque.PopBottom(); j != nil; j = w.deque.PopBottom() {
+		jobs = append(jobs, j)
+	}
+	for _, j := range jobs {
+		pool.enqueue(j)
+	}
+	pool.removeIdle(w)
+}
+
+// recordWait folds a queue wait time into an exponentially weighted average.
+func (pool *WorkStealingPool) recordWait(d time.Duration) {
+	for {
+		old := pool.waitEWMA.Load()
+		updated := old - old/8 + int64(d)/8
+		if pool.waitEWMA.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+func (pool *WorkStealingPool) autoscale(opts AutoscaleOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = 100 * time.Millisecond
+	}
+	opts.MinWorkers = max(opts.MinWorkers, 1)
+	if opts.MaxWorkers < opts.MinWorkers {
+		opts.MaxWorkers = opts.MinWorkers
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pool.stopAutoscale:
+			return
+		case <-ticker.C:
+		}
+
+		workers := pool.snapshot()
+		n := len(workers)
+		var depth int64
+		idle := 0
+		now := time.Now().UnixNano()
+		for _, w := range workers {
+			depth += w.depth()
+			if opts.IdleTimeout > 0 && w.parked.Load() && now-w.lastActive.Load() > int64(opts.IdleTimeout) {
+				idle++
+			}
+		}
+
+		if depth == 0 {
+			// Nothing is queued, so the last measured wait is stale.
+			pool.waitEWMA.Store(0)
+		}
+		busy := (opts.QueueDepth > 0 && depth > int64(opts.QueueDepth*n)) ||
+			(opts.MaxWait > 0 && time.Duration(pool.waitEWMA.Load()) > opts.MaxWait)
+		switch {
+		case busy && n < opts.MaxWorkers:
+			pool.Resize(n + 1)
+		case !busy && idle > 0 && n > opts.MinWorkers:
+			pool.Resize(max(n-idle, opts.MinWorkers))
+		}
+	}
+}
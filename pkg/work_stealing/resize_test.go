@@ -0,0 +1,77 @@
+package workstealing
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResizeKeepsTasks(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+
+	const tasks = 5000
+	runs := make([]atomic.Int32, tasks)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, n := range []int{1, 8, 2, 6, 3} {
+			pool.Resize(n)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	for i := 0; i < tasks; i++ {
+		pool.Submit(func() {
+			runs[i].Add(1)
+			if i%100 == 0 {
+				time.Sleep(100 * time.Microsecond)
+			}
+		})
+	}
+	<-done
+	pool.Wait()
+
+	for i := range runs {
+		if n := runs[i].Load(); n != 1 {
+			t.Fatalf("task %d ran %d times", i, n)
+		}
+	}
+	if pool.Size() != 3 {
+		t.Errorf("expected 3 workers, got %d", pool.Size())
+	}
+}
+
+func TestAutoscale(t *testing.T) {
+	pool := NewWorkStealingPoolWithOptions(1, PoolOptions{
+		Autoscale: &AutoscaleOptions{
+			MinWorkers:  1,
+			MaxWorkers:  4,
+			Interval:    5 * time.Millisecond,
+			QueueDepth:  2,
+			IdleTimeout: 20 * time.Millisecond,
+		},
+	})
+	defer pool.ShutdownNow()
+
+	release := make(chan struct{})
+	for i := 0; i < 40; i++ {
+		pool.Submit(func() { <-release })
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Size() < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if pool.Size() != 4 {
+		t.Errorf("expected pool to grow to 4 workers, got %d", pool.Size())
+	}
+
+	close(release)
+	pool.Wait()
+	deadline = time.Now().Add(2 * time.Second)
+	for pool.Size() > 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if pool.Size() != 1 {
+		t.Errorf("expected idle workers to retire down to 1, got %d", pool.Size())
+	}
+}
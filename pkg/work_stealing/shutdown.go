@@ -0,0 +1,104 @@
+package workstealing
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrPoolClosed is returned by Submit once the pool is shutting down.
+var ErrPoolClosed = errors.New("pool is closed")
+
+// Wait blocks until every submitted task, including tasks submitted while
+// waiting, has finished. It does not stop the pool.
+func (pool *WorkStealingPool) Wait() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for pool.pending.Load() > 0 {
+		pool.idle.Wait()
+	}
+}
+
+// Shutdown stops accepting tasks and waits for queued and running tasks to
+// finish and for the workers to exit. If ctx is done first it returns
+// ctx.Err(); the workers keep draining the queues in the background.
+func (pool *WorkStealingPool) Shutdown(ctx context.Context) error {
+	pool.close()
+	if pool.pending.Load() == 0 {
+		pool.wakeAll()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		pool.Wait()
+		pool.workersWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownNow stops accepting tasks, lets each worker finish the task it
+// is running and returns the tasks that never started.
+func (pool *WorkStealingPool) ShutdownNow() []Task {
+	pool.stopped.Store(true)
+	pool.close()
+	pool.wakeAll()
+	pool.workersWG.Wait()
+
+	// The workers have exited, so nothing else touches the queues. Only
+	// submitted tasks and fork-join roots can be left: a fork-join task
+	// joins all of its subtasks before its worker moves on. Keyed tasks
+	// are returned after the head tasks, so each key keeps its order.
+	var jobs []*job
+	for _, w := range pool.snapshot() {
+		for j := w.deque.Steal(); j != nil; j = w.deque.Steal() {
+			jobs = append(jobs, j)
+		}
+		w.mu.Lock()
+		jobs = append(jobs, w.inbox.TakeAll()...)
+		w.inboxLen.Store(0)
+		w.mu.Unlock()
+	}
+	jobs = append(jobs, pool.takeKeyed()...)
+	tasks := make([]Task, 0, len(jobs))
+	for _, j := range jobs {
+		if j.task != nil {
+			tasks = append(tasks, j.task)
+		}
+		if j.dropped != nil {
+			j.dropped()
+		}
+		pool.taskDone()
+	}
+	return tasks
+}
+
+// close rejects new tasks and resizes and stops the autoscaler. Once it
+// returns, every submit that got past the closed check has queued its task.
+func (pool *WorkStealingPool) close() {
+	pool.resizeMu.Lock()
+	pool.submitMu.Lock()
+	pool.closed.Store(true)
+	pool.submitMu.Unlock()
+	pool.resizeMu.Unlock()
+	if pool.stopAutoscale != nil {
+		pool.autoscaleOnce.Do(func() { close(pool.stopAutoscale) })
+	}
+}
+
+// taskDone marks one submitted task as finished or discarded.
+func (pool *WorkStealingPool) taskDone() {
+	if pool.pending.Add(-1) == 0 {
+		pool.mu.Lock()
+		pool.idle.Broadcast()
+		pool.mu.Unlock()
+		if pool.closed.Load() {
+			pool.wakeAll()
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package workstealing
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForTasks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	pool := NewWorkStealingPool(4)
+	var count atomic.Int64
+	for i := 0; i < 100; i++ {
+		pool.Submit(func() {
+			time.Sleep(time.Millisecond)
+			count.Add(1)
+		})
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count.Load() != 100 {
+		t.Errorf("expected 100 tasks, got %d", count.Load())
+	}
+	if err := pool.Submit(func() {}); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected workers to exit, %d goroutines left", n-before)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	release := make(chan struct{})
+	pool.Submit(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	close(release)
+	pool.Wait()
+}
+
+func TestShutdownNow(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	var ran atomic.Int64
+	for i := 0; i < 5; i++ {
+		pool.Submit(func() { ran.Add(1) })
+	}
+
+	result := make(chan []Task)
+	go func() { result <- pool.ShutdownNow() }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if tasks := <-result; len(tasks) != 5 {
+		t.Errorf("expected 5 unstarted tasks, got %d", len(tasks))
+	}
+	if ran.Load() != 0 {
+		t.Errorf("expected no queued task to run, %d ran", ran.Load())
+	}
+	pool.Wait()
+}
+
+// TestShutdownNowRacingSubmit checks that a task submitted while
+// ShutdownNow drains the queues is either rejected, run or handed back,
+// so that Wait does not hang on a lost task.
+func TestShutdownNowRacingSubmit(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		pool := NewWorkStealingPool(2)
+		var accepted, ran atomic.Int64
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		submits := []func(i int) error{
+			func(int) error { return pool.Submit(func() { ran.Add(1) }) },
+			func(i int) error {
+				return pool.SubmitKeyed(strconv.Itoa(i%3), func() { ran.Add(1) })
+			},
+			func(int) error {
+				return pool.SubmitContext(context.Background(), func(context.Context) { ran.Add(1) })
+			},
+			func(int) error {
+				return pool.SubmitForkJoin(func(*ForkJoin) { ran.Add(1) })
+			},
+		}
+		for k := 0; k < 8; k++ {
+			submit := submits[k%len(submits)]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for i := 0; i < 200; i++ {
+					if submit(i) == nil {
+						accepted.Add(1)
+					}
+				}
+			}()
+		}
+		close(start)
+		for accepted.Load() < 20 {
+			runtime.Gosched()
+		}
+		tasks := pool.ShutdownNow()
+		wg.Wait()
+
+		waited := make(chan struct{})
+		go func() {
+			pool.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round %d: Wait hung with %d tasks pending", round, pool.pending.Load())
+		}
+		if got := ran.Load() + int64(len(tasks)); got != accepted.Load() {
+			t.Fatalf("round %d: %d tasks accepted, but %d ran and %d were returned",
+				round, accepted.Load(), ran.Load(), len(tasks))
+		}
+	}
+}
+
+func TestShutdownNowWaitsForInFlightSubmit(t *testing.T) {
+	pool := NewWorkStealingPool(1)
+	// A submit that has passed the closed check but not queued its task yet.
+	pool.submitMu.RLock()
+	pool.pending.Add(1)
+
+	result := make(chan []Task)
+	go func() { result <- pool.ShutdownNow() }()
+	select {
+	case <-result:
+		t.Fatal("ShutdownNow drained the queues during a submit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	pool.enqueue(&job{task: func() {}})
+	pool.submitMu.RUnlock()
+
+	if tasks := <-result; len(tasks) != 1 {
+		t.Errorf("expected the in-flight task to be returned, got %d", len(tasks))
+	}
+	pool.Wait()
+}
+
+func TestWait(t *testing.T) {
+	pool := NewWorkStealingPool(2)
+	defer pool.ShutdownNow()
+
+	var count atomic.Int64
+	for i := 0; i < 10; i++ {
+		pool.Submit(func() {
+			// Work submitted from a running task is waited for too.
+			pool.Submit(func() { count.Add(1) })
+			count.Add(1)
+		})
+	}
+	pool.Wait()
+	if count.Load() != 20 {
+		t.Errorf("expected 20 tasks, got %d", count.Load())
+	}
+}
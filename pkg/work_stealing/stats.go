@@ -0,0 +1,66 @@
+package workstealing
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+type workerCounters struct {
+	executed     atomic.Uint64
+	stolen       atomic.Uint64
+	stolenFrom   atomic.Uint64
+	failedSteals atomic.Uint64
+	parks        atomic.Uint64
+	unparks      atomic.Uint64
+}
+
+// WorkerStats is a snapshot of one worker. Stolen counts tasks this worker
+// took from others and StolenFrom tasks others took from it. FailedSteals
+// counts passes over all victims that found nothing.
+type WorkerStats struct {
+	ID           int
+	Executed     uint64
+	Stolen       uint64
+	StolenFrom   uint64
+	FailedSteals uint64
+	Depth        int64
+	Parks        uint64
+	Unparks      uint64
+}
+
+// PoolStats is a snapshot of the pool. Retired workers are not included.
+type PoolStats struct {
+	Workers []WorkerStats
+	Pending int64
+	Failed  uint64
+}
+
+func (pool *WorkStealingPool) Stats() PoolStats {
+	workers := pool.snapshot()
+	st := PoolStats{
+		Workers: make([]WorkerStats, len(workers)),
+		Pending: pool.pending.Load(),
+		Failed:  pool.failed.Load(),
+	}
+	for i, w := range workers {
+		st.Workers[i] = WorkerStats{
+			ID:           w.id,
+			Executed:     w.stats.executed.Load(),
+			Stolen:       w.stats.stolen.Load(),
+			StolenFrom:   w.stats.stolenFrom.Load(),
+			FailedSteals: w.stats.failedSteals.Load(),
+			Depth:        w.depth(),
+			Parks:        w.stats.parks.Load(),
+			Unparks:      w.stats.unparks.Load(),
+		}
+	}
+	return st
+}
+
+// PublishExpvar exposes Stats under name on /debug/vars. Like
+// expvar.Publish, it panics if name is already in use.
+func (pool *WorkStealingPool) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return pool.Stats()
+	}))
+}
@@ -0,0 +1,53 @@
+package workstealing
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// expvarRuns keeps published names unique across -count runs.
+var expvarRuns atomic.Int32
+
+func TestPoolStats(t *testing.T) {
+	pool := NewWorkStealingPoolWithOptions(2, PoolOptions{SubmitPolicy: LocalFirst})
+	defer pool.ShutdownNow()
+
+	// One task fans out onto its own deque, so the other worker has to steal.
+	pool.Submit(func() {
+		for i := 0; i < 100; i++ {
+			pool.Submit(func() { time.Sleep(100 * time.Microsecond) })
+		}
+	})
+	pool.Wait()
+
+	st := pool.Stats()
+	if len(st.Workers) != 2 {
+		t.Fatalf("expected 2 workers, got %d", len(st.Workers))
+	}
+	var executed, stolen, stolenFrom uint64
+	for _, w := range st.Workers {
+		executed += w.Executed
+		stolen += w.Stolen
+		stolenFrom += w.StolenFrom
+	}
+	if executed != 101 {
+		t.Errorf("expected 101 executed tasks, got %d", executed)
+	}
+	if stolen == 0 || stolen != stolenFrom {
+		t.Errorf("expected matching non-zero steal counts, got %d stolen and %d stolen from", stolen, stolenFrom)
+	}
+
+	name := fmt.Sprintf("workstealing_test_pool_%d", expvarRuns.Add(1))
+	pool.PublishExpvar(name)
+	var published PoolStats
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &published); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(published.Workers) != 2 {
+		t.Errorf("expected expvar to publish 2 workers, got %d", len(published.Workers))
+	}
+}
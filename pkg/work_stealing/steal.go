@@ -0,0 +1,102 @@
+package workstealing
+
+import "math/rand/v2"
+
+const defaultStealRounds = 2
+
+// stealTask runs on w's goroutine. Each round visits every other worker
+// once, starting at a random one so that thieves do not all pile onto the
+// same victims. Another round is only made when a victim had work but the
+// steal lost a race for it.
+func (pool *WorkStealingPool) stealTask(w *Worker) *job {
+	stealers := *w.stealers.Load()
+	if len(stealers) == 0 {
+		return nil
+	}
+	rounds := pool.opts.StealRounds
+	if rounds <= 0 {
+		rounds = defaultStealRounds
+	}
+	for r := 0; r < rounds; r++ {
+		contended := false
+		start := rand.IntN(len(stealers))
+		for i := range stealers {
+			victim := stealers[(start+i)%len(stealers)]
+			if victim.deque.Size() > 0 {
+				if j := pool.stealDeque(w, victim); j != nil {
+					return j
+				}
+				contended = true
+			}
+			// Tasks still waiting in an inbox are only reachable under its lock.
+			if victim.inboxLen.Load() > 0 {
+				if j := pool.stealInbox(w, victim); j != nil {
+					return j
+				}
+				contended = true
+			}
+		}
+		if !contended {
+			break
+		}
+	}
+	w.stats.failedSteals.Add(1)
+	return nil
+}
+
+// stealDeque takes the oldest task from victim's deque. With StealHalf,
+// up to half of the deque is moved and everything but the first task is
+// pushed onto w's own deque, where others can steal it in turn.
+func (pool *WorkStealingPool) stealDeque(w, victim *Worker) *job {
+	first := victim.deque.Steal()
+	if first == nil {
+		return nil
+	}
+	n := 1
+	if pool.opts.StealHalf {
+		for batch := victim.deque.Size() / 2; batch > 0; batch-- {
+			j := victim.deque.Steal()
+			if j == nil {
+				break
+			}
+			w.deque.PushBottom(j)
+			n++
+		}
+	}
+	pool.recordSteals(w, victim, n)
+	return first
+}
+
+// stealInbox is stealDeque for tasks still in victim's inbox.
+func (pool *WorkStealingPool) stealInbox(w, victim *Worker) *job {
+	victim.mu.Lock()
+	first := victim.inbox.PopFront()
+	if first == nil {
+		victim.mu.Unlock()
+		return nil
+	}
+	var rest []*job
+	if pool.opts.StealHalf {
+		for batch := victim.inbox.Len() / 2; batch > 0; batch-- {
+			rest = append(rest, victim.inbox.PopFront())
+		}
+	}
+	victim.inboxLen.Add(-int64(1 + len(rest)))
+	victim.mu.Unlock()
+
+	for _, j := range rest {
+		w.deque.PushBottom(j)
+	}
+	pool.recordSteals(w, victim, 1+len(rest))
+	return first
+}
+
+func (pool *WorkStealingPool) recordSteals(w, victim *Worker, n int) {
+	w.stats.stolen.Add(uint64(n))
+	victim.stats.stolenFrom.Add(uint64(n))
+	if n > 1 {
+		// w runs the first task; the rest now sits in w's deque, so an
+		// idle worker may help with it.
+		pool.wake(nil)
+	}
+}
@@ -0,0 +1,124 @@
+package workstealing
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestWorkers builds workers that are not running, so their queues can
+// be inspected.
+func newTestWorkers(n int) []*Worker {
+	workers := make([]*Worker, n)
+	for i := range workers {
+		workers[i] = &Worker{id: i, deque: newDeque(), inbox: &queue{}}
+	}
+	for _, w := range workers {
+		var stealers []*Worker
+		for _, other := range workers {
+			if other != w {
+				stealers = append(stealers, other)
+			}
+		}
+		w.stealers.Store(&stealers)
+	}
+	return workers
+}
+
+func TestStealHalf(t *testing.T) {
+	pool := &WorkStealingPool{opts: PoolOptions{StealHalf: true}}
+	workers := newTestWorkers(2)
+	thief, victim := workers[0], workers[1]
+	for i := 0; i < 10; i++ {
+		victim.deque.PushBottom(&job{})
+	}
+
+	if j := pool.stealTask(thief); j == nil {
+		t.Fatal("expected a stolen task")
+	}
+	if got := victim.deque.Size(); got != 5 {
+		t.Errorf("expected 5 tasks left on the victim, got %d", got)
+	}
+	if got := thief.deque.Size(); got != 4 {
+		t.Errorf("expected 4 tasks moved to the thief, got %d", got)
+	}
+	if got := thief.stats.stolen.Load(); got != 5 {
+		t.Errorf("expected 5 stolen tasks, got %d", got)
+	}
+
+	for i := 0; i < 6; i++ {
+		victim.inbox.PushBack(&job{})
+	}
+	victim.inboxLen.Store(6)
+	for victim.deque.Size() > 0 {
+		victim.deque.PopBottom()
+	}
+	if j := pool.stealTask(thief); j == nil {
+		t.Fatal("expected a task stolen from the inbox")
+	}
+	if got := victim.inboxLen.Load(); got != 3 {
+		t.Errorf("expected 3 tasks left in the inbox, got %d", got)
+	}
+}
+
+func TestStealSingle(t *testing.T) {
+	pool := &WorkStealingPool{}
+	workers := newTestWorkers(4)
+	for _, w := range workers[1:] {
+		w.deque.PushBottom(&job{})
+	}
+
+	// Random victim order still finds every task.
+	for i := 0; i < 3; i++ {
+		if j := pool.stealTask(workers[0]); j == nil {
+			t.Fatalf("expected a task on steal %d", i)
+		}
+	}
+	if j := pool.stealTask(workers[0]); j != nil {
+		t.Error("expected nothing left to steal")
+	}
+	if got := workers[0].stats.failedSteals.Load(); got != 1 {
+		t.Errorf("expected 1 failed steal, got %d", got)
+	}
+}
+
+// BenchmarkSkewedSteal queues all tasks on one worker from inside a task,
+// so every other worker has to steal them. One task in skew is much
+// heavier than the rest.
+func BenchmarkSkewedSteal(b *testing.B) {
+	const tasks = 1000
+	for _, half := range []bool{false, true} {
+		for _, skew := range []int{1, 10, 100} {
+			b.Run(fmt.Sprintf("half-%v/skew-%d", half, skew), func(b *testing.B) {
+				pool := NewWorkStealingPoolWithOptions(8, PoolOptions{SubmitPolicy: LocalFirst, StealHalf: half})
+				defer pool.ShutdownNow()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					var wg sync.WaitGroup
+					wg.Add(tasks)
+					pool.Submit(func() {
+						for k := 0; k < tasks; k++ {
+							d := time.Microsecond
+							if k%skew == 0 {
+								d = 50 * time.Microsecond
+							}
+							pool.Submit(func() {
+								spin(d)
+								wg.Done()
+							})
+						}
+					})
+					wg.Wait()
+				}
+			})
+		}
+	}
+}
+
+// spin burns CPU for d; sleeping would let the scheduler hide the cost of
+// stealing.
+func spin(d time.Duration) {
+	for start := time.Now(); time.Since(start) < d; {
+	}
+}
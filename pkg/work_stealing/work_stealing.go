@@ -1,7 +1,13 @@
 package workstealing
 
 import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -22,124 +28,367 @@ Work Stealing Pool:
 - Эффективное распределение задач
 */
 
-// Simple slice-based queue
+// Simple slice-based FIFO queue. Popped slots are cleared and the slice
+// is reused once it empties, so it does not grow without bound.
 type queue struct {
-	tasks []Task
+	jobs []*job
+	head int
 }
 
-func (q *queue) PushBack(t Task) {
-	q.tasks = append(q.tasks, t)
+func (q *queue) PushBack(j *job) {
+	q.jobs = append(q.jobs, j)
 }
 
-func (q *queue) PopFront() Task {
-	if len(q.tasks) == 0 {
+func (q *queue) PopFront() *job {
+	if q.head == len(q.jobs) {
 		return nil
 	}
-	t := q.tasks[0]
-	q.tasks = q.tasks[1:]
-	return t
+	j := q.jobs[q.head]
+	q.jobs[q.head] = nil
+	q.head++
+	if q.head == len(q.jobs) {
+		q.jobs = q.jobs[:0]
+		q.head = 0
+	}
+	return j
 }
 
-func (q *queue) PopBack() Task {
-	if len(q.tasks) == 0 {
-		return nil
-	}
-	index := len(q.tasks) - 1
-	t := q.tasks[index]
-	q.tasks = q.tasks[:index]
-	return t
+// TakeAll removes and returns every queued job in FIFO order.
+func (q *queue) TakeAll() []*job {
+	jobs := q.jobs[q.head:]
+	q.jobs = nil
+	q.head = 0
+	return jobs
+}
+
+func (q *queue) Len() int {
+	return len(q.jobs) - q.head
 }
 
 type Task func()
 
+// job is what the queues hold: either a submitted Task or a fork-join
+// task, which needs to know the worker it runs on. Fork-join roots also
+// carry a task, so that ShutdownNow can hand them back.
+type job struct {
+	task Task
+	fork func(w *Worker)
+	// keyed is set on the head task of a key, see SubmitKeyed.
+	keyed *keyedQueue
+	// ctx is set by SubmitContext; the task is skipped once it is done.
+	ctx context.Context
+	// dropped is called when ShutdownNow returns the job unstarted.
+	dropped func()
+	// enqueued is only set when the autoscaler tracks queue wait times.
+	enqueued time.Time
+}
+
+// Worker owns a lock-free deque for tasks it queues itself and a
+// mutex-protected inbox for tasks submitted from outside, because only
+// the owner may push onto a Chase-Lev deque.
 type Worker struct {
-	id          int
-	localQueue  *queue
-	stealers    []*Worker
-	mu          sync.Mutex
-	cond        *sync.Cond
-	stolenTasks chan Task
+	id    int
+	deque *deque
+	inbox *queue
+	// stealers is replaced as a whole when the pool is resized.
+	stealers atomic.Pointer[[]*Worker]
+	mu       sync.Mutex
+	cond     *sync.Cond
+	// notified is set under mu by the waker of a parked worker.
+	notified bool
+	// inboxLen mirrors inbox.Len() so others can read it without the lock.
+	inboxLen atomic.Int64
+
+	// retiring asks the worker to hand off its queues and exit; retired is
+	// set under mu once the inbox has been handed off.
+	retiring atomic.Bool
+	retired  bool
+	parked   atomic.Bool
+	// lastActive is the UnixNano time the worker last finished a task.
+	lastActive atomic.Int64
+
+	stats workerCounters
+}
+
+// depth is the number of tasks waiting in the worker's deque and inbox.
+func (w *Worker) depth() int64 {
+	return w.deque.Size() + w.inboxLen.Load()
+}
+
+// SubmitPolicy selects the worker whose local queue receives a submitted task.
+type SubmitPolicy int
+
+const (
+	// RoundRobin cycles through the workers.
+	RoundRobin SubmitPolicy = iota
+	// Random picks a worker uniformly at random.
+	Random
+	// LeastLoaded picks the worker with the shortest local queue.
+	LeastLoaded
+	// LocalFirst queues onto the calling worker when Submit is called from
+	// inside a task, and falls back to RoundRobin otherwise. Finding the
+	// caller costs a stack parse per Submit; a fork-join task can queue
+	// onto its worker directly with ForkJoin.Submit instead.
+	LocalFirst
+)
+
+type PoolOptions struct {
+	SubmitPolicy SubmitPolicy
+	// OnPanic is called with the recovered value and stack of a panicking
+	// task. The worker keeps running either way.
+	OnPanic func(value interface{}, stack []byte)
+	// Autoscale enables the autoscaler when set.
+	Autoscale *AutoscaleOptions
+	// OnExpired is called for a SubmitContext task that is skipped because
+	// its deadline passed while it was queued.
+	OnExpired func(ctx context.Context)
+	// StealRounds bounds how many passes over the other workers a thief
+	// makes while steals keep losing races; 2 by default.
+	StealRounds int
+	// StealHalf makes a thief take up to half of a victim's queued tasks
+	// at once instead of a single one.
+	StealHalf bool
 }
 
 type WorkStealingPool struct {
-	workers []*Worker
-	size    int
+	// workers is an immutable snapshot replaced by Resize.
+	workers atomic.Pointer[[]*Worker]
+	opts    PoolOptions
+	next    atomic.Uint64
+	// byGoroutine maps a worker goroutine ID to its Worker. It is only
+	// filled in under LocalFirst.
+	byGoroutine sync.Map
+
+	// pending counts submitted tasks that have not finished yet; idle is
+	// broadcast on mu when it drops to zero.
+	pending atomic.Int64
+	mu      sync.Mutex
+	idle    *sync.Cond
+	// closed rejects new tasks; stopped makes workers exit after their
+	// current task even if work is queued.
+	closed atomic.Bool
+	// submitMu is held for reading from the closed check to the enqueue
+	// of a submit, and for writing by close, so that ShutdownNow never
+	// drains the queues while a task it cannot see is being queued.
+	submitMu  sync.RWMutex
+	stopped   atomic.Bool
+	workersWG sync.WaitGroup
+	// failed counts tasks that panicked.
+	failed atomic.Uint64
+
+	// Parked workers, see park.go.
+	idleMu      sync.Mutex
+	idleWorkers []*Worker
+	idleCount   atomic.Int32
+
+	// Resizing and autoscaling, see resize.go.
+	resizeMu      sync.Mutex
+	nextID        int
+	waitEWMA      atomic.Int64
+	stopAutoscale chan struct{}
+	autoscaleOnce sync.Once
+
+	// Keys with a queued or running task, see keyed.go.
+	keyedMu sync.Mutex
+	keyed   map[string]*keyedQueue
 }
 
 func NewWorkStealingPool(size int) *WorkStealingPool {
-	pool := &WorkStealingPool{
-		workers: make([]*Worker, size),
-		size:    size,
-	}
-
-	// Initialize workers
-	for i := 0; i < size; i++ {
-		pool.workers[i] = &Worker{
-			id: i,
-			localQueue: &queue{
-				tasks: []Task{},
-			},
-			stolenTasks: make(chan Task, 1),
-		}
+	return NewWorkStealingPoolWithOptions(size, PoolOptions{})
+}
+
+func NewWorkStealingPoolWithOptions(size int, opts PoolOptions) *WorkStealingPool {
+	pool := &WorkStealingPool{opts: opts}
+	pool.idle = sync.NewCond(&pool.mu)
+	pool.workers.Store(&[]*Worker{})
+	pool.resize(max(size, 1))
+
+	if opts.Autoscale != nil {
+		pool.stopAutoscale = make(chan struct{})
+		go pool.autoscale(*opts.Autoscale)
 	}
+	return pool
+}
 
-	// Setup steal relationships
-	for i := 0; i < size; i++ {
-		worker := pool.workers[i]
-		worker.stealers = make([]*Worker, size-1)
-		copy(worker.stealers, pool.workers[:i])
-		copy(worker.stealers[i:], pool.workers[i+1:])
-		worker.cond = sync.NewCond(&worker.mu)
+// snapshot returns the current workers. The slice must not be modified.
+func (pool *WorkStealingPool) snapshot() []*Worker {
+	return *pool.workers.Load()
+}
+
+// Submit queues a task. It returns ErrPoolClosed after Shutdown or ShutdownNow.
+func (pool *WorkStealingPool) Submit(task Task) error {
+	return pool.submit(&job{task: task})
+}
+
+// submit queues j unless the pool is closed.
+func (pool *WorkStealingPool) submit(j *job) error {
+	pool.submitMu.RLock()
+	defer pool.submitMu.RUnlock()
+	if pool.closed.Load() {
+		return ErrPoolClosed
 	}
+	pool.pending.Add(1)
+	pool.enqueue(j)
+	return nil
+}
 
-	// Start workers
-	for _, w := range pool.workers {
-		go pool.runWorker(w)
+func (pool *WorkStealingPool) enqueue(j *job) {
+	if pool.opts.Autoscale != nil && j.enqueued.IsZero() {
+		j.enqueued = time.Now()
+	}
+	for {
+		w, local := pool.pickWorker()
+		if local {
+			// Called from a task running on w: the owner can push directly
+			// and an idle worker is woken to steal it.
+			w.deque.PushBottom(j)
+			pool.wake(nil)
+			return
+		}
+		w.mu.Lock()
+		if w.retired {
+			// Picked from a stale snapshot; w has already handed off its inbox.
+			w.mu.Unlock()
+			continue
+		}
+		w.inbox.PushBack(j)
+		w.inboxLen.Add(1)
+		w.mu.Unlock()
+		pool.wake(w)
+		return
 	}
+}
 
-	return pool
+// pickWorker chooses a worker according to the submit policy. local is
+// true when the caller is running on the returned worker's goroutine.
+func (pool *WorkStealingPool) pickWorker() (w *Worker, local bool) {
+	workers := pool.snapshot()
+	switch pool.opts.SubmitPolicy {
+	case Random:
+		return workers[rand.IntN(len(workers))], false
+	case LeastLoaded:
+		best := workers[0]
+		bestDepth := best.depth()
+		for _, w := range workers[1:] {
+			if d := w.depth(); d < bestDepth {
+				best, bestDepth = w, d
+			}
+		}
+		return best, false
+	case LocalFirst:
+		if w, ok := pool.byGoroutine.Load(goroutineID()); ok {
+			return w.(*Worker), true
+		}
+	}
+	return workers[(pool.next.Add(1)-1)%uint64(len(workers))], false
 }
 
-func (pool *WorkStealingPool) Submit(task Task) {
-	pool.workers[0].mu.Lock()
-	defer pool.workers[0].mu.Unlock()
-	pool.workers[0].localQueue.PushBack(task)
-	pool.workers[0].cond.Signal()
+// goroutineID parses the current goroutine ID from the stack header
+// "goroutine 123 [running]:". Go has no goroutine-local storage, and this
+// is only used by LocalFirst to tell whether Submit runs inside a task.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = b[len("goroutine "):]
+	for i, c := range b {
+		if c == ' ' {
+			b = b[:i]
+			break
+		}
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
 }
 
 func (pool *WorkStealingPool) runWorker(w *Worker) {
+	defer pool.workersWG.Done()
+	// Only LocalFirst looks workers up by goroutine, so the others skip
+	// the stack parse.
+	var gid uint64
+	if pool.opts.SubmitPolicy == LocalFirst {
+		gid = goroutineID()
+		pool.byGoroutine.Store(gid, w)
+		defer pool.byGoroutine.Delete(gid)
+	}
+
+	spins := 0
 	for {
-		task := w.getTask()
-		if task == nil {
+		// A retiring worker hands off its queues even when the pool is
+		// stopping, so that ShutdownNow finds them on the other workers.
+		if w.retiring.Load() {
+			pool.byGoroutine.Delete(gid)
+			pool.retire(w)
+			return
+		}
+		if pool.stopped.Load() {
+			return
+		}
+		j := pool.getTask(w)
+		if j == nil {
 			// Try to steal from other workers
-			task = w.stealTask()
+			j = pool.stealTask(w)
 		}
-		if task != nil {
-			task()
+		if j != nil {
+			pool.run(w, j)
+			spins = 0
+			continue
 		}
+		if pool.closed.Load() && pool.pending.Load() == 0 {
+			return
+		}
+		if spins < idleSpins {
+			spins++
+			runtime.Gosched()
+			continue
+		}
+		pool.park(w)
+		spins = 0
 	}
 }
 
-func (w *Worker) getTask() Task {
+// getTask pops from the worker's own deque. When it is empty, the inbox
+// is moved into the deque so that thieves can take those tasks lock-free.
+func (pool *WorkStealingPool) getTask(w *Worker) *job {
+	if j := w.deque.PopBottom(); j != nil {
+		return j
+	}
+
 	w.mu.Lock()
-	defer w.mu.Unlock()
-	if len(w.localQueue.tasks) > 0 {
-		return w.localQueue.PopFront()
-	}
-	select {
-	case t := <-w.stolenTasks:
-		return t
-	default:
+	jobs := w.inbox.TakeAll()
+	w.inboxLen.Store(0)
+	w.mu.Unlock()
+	if len(jobs) == 0 {
 		return nil
 	}
+	// Push newest first so the owner keeps FIFO order popping from the
+	// bottom while thieves take the newest tasks from the top.
+	for i := len(jobs) - 1; i > 0; i-- {
+		w.deque.PushBottom(jobs[i])
+	}
+	if len(jobs) > 1 {
+		pool.wake(nil)
+	}
+	return jobs[0]
 }
 
-func (w *Worker) stealTask() Task {
-	// Randomly try to steal from other workers
-	for _, victim := range w.stealers {
-		if task := victim.localQueue.PopBack(); task != nil {
-			return task
+// run executes a job on w. Fork-join jobs do their own accounting.
+func (pool *WorkStealingPool) run(w *Worker, j *job) {
+	if !j.enqueued.IsZero() {
+		pool.recordWait(time.Since(j.enqueued))
+	}
+	w.stats.executed.Add(1)
+	if j.fork != nil {
+		j.fork(w)
+	} else {
+		if j.ctx == nil || !pool.skipDone(j.ctx) {
+			pool.safeRun(j.task)
 		}
+		if j.keyed != nil {
+			pool.keyedDone(j.keyed)
+		}
+		pool.taskDone()
+	}
+	if pool.opts.Autoscale != nil {
+		w.lastActive.Store(time.Now().UnixNano())
 	}
-	return nil
 }
@@ -1,7 +1,11 @@
 package workstealing
 
 import (
+	"context"
+	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -16,3 +20,118 @@ func TestWorkStealingPool(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestSubmitPolicies(t *testing.T) {
+	for _, policy := range []SubmitPolicy{RoundRobin, Random, LeastLoaded, LocalFirst} {
+		pool := NewWorkStealingPoolWithOptions(4, PoolOptions{SubmitPolicy: policy})
+		var wg sync.WaitGroup
+		var count atomic.Int64
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			pool.Submit(func() {
+				count.Add(1)
+				wg.Done()
+			})
+		}
+		wg.Wait()
+		if count.Load() != 100 {
+			t.Errorf("policy %d: expected 100 tasks, got %d", policy, count.Load())
+		}
+		pool.ShutdownNow()
+	}
+}
+
+func TestSubmitLocalFirst(t *testing.T) {
+	pool := NewWorkStealingPoolWithOptions(4, PoolOptions{SubmitPolicy: LocalFirst})
+	defer pool.ShutdownNow()
+	done := make(chan bool)
+	pool.Submit(func() {
+		w, _ := pool.byGoroutine.Load(goroutineID())
+		picked, local := pool.pickWorker()
+		done <- local && picked == w
+	})
+	if !<-done {
+		t.Errorf("expected Submit from a task to pick the calling worker")
+	}
+}
+
+func TestSubmitRoundRobinSkipsGoroutineLookup(t *testing.T) {
+	pool := NewWorkStealingPool(4)
+	defer pool.ShutdownNow()
+	pool.Submit(func() {})
+	pool.Wait()
+	pool.byGoroutine.Range(func(key, _ any) bool {
+		t.Errorf("expected no goroutine registered outside LocalFirst, found %v", key)
+		return true
+	})
+}
+
+func BenchmarkSubmit(b *testing.B) {
+	policies := []struct {
+		name   string
+		policy SubmitPolicy
+	}{
+		{"RoundRobin", RoundRobin},
+		{"Random", Random},
+		{"LeastLoaded", LeastLoaded},
+		{"LocalFirst", LocalFirst},
+	}
+	for _, p := range policies {
+		pool := NewWorkStealingPoolWithOptions(runtime.GOMAXPROCS(0), PoolOptions{SubmitPolicy: p.policy})
+		for _, submitters := range []int{1, 4, 16, 64} {
+			b.Run(fmt.Sprintf("%s/submitters-%d", p.name, submitters), func(b *testing.B) {
+				var wg sync.WaitGroup
+				wg.Add(b.N)
+				per := b.N / submitters
+				b.ResetTimer()
+				for s := 0; s < submitters; s++ {
+					n := per
+					if s == 0 {
+						n += b.N % submitters
+					}
+					go func() {
+						for i := 0; i < n; i++ {
+							pool.Submit(wg.Done)
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		}
+		pool.Shutdown(context.Background())
+	}
+}
+
+func TestWorkStealingPoolExactlyOnce(t *testing.T) {
+	const submitters = 4
+	const perSubmitter = 2000
+
+	pool := NewWorkStealingPoolWithOptions(4, PoolOptions{SubmitPolicy: LocalFirst})
+	defer pool.ShutdownNow()
+	runs := make([]atomic.Int32, submitters*perSubmitter*2)
+	var wg sync.WaitGroup
+	wg.Add(len(runs))
+	for s := 0; s < submitters; s++ {
+		go func() {
+			for i := 0; i < perSubmitter; i++ {
+				id := (s*perSubmitter + i) * 2
+				pool.Submit(func() {
+					runs[id].Add(1)
+					// Queued onto this worker's own deque.
+					pool.Submit(func() {
+						runs[id+1].Add(1)
+						wg.Done()
+					})
+					wg.Done()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range runs {
+		if n := runs[i].Load(); n != 1 {
+			t.Fatalf("task %d ran %d times", i, n)
+		}
+	}
+}